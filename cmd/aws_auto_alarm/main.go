@@ -5,20 +5,17 @@ import (
 	"os"
 
 	"github.com/rs/zerolog"
-	"github.com/spf13/pflag"
 
 	"github.com/akijowski/aws-auto-alarm/internal/awsclient"
 	"github.com/akijowski/aws-auto-alarm/internal/cli"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+	"github.com/akijowski/aws-auto-alarm/internal/template"
+	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
 )
 
 func main() {
 	ctx := zerolog.New(zerolog.NewConsoleWriter()).With().Timestamp().Logger().WithContext(context.Background())
 
-	pflag.StringP("file", "f", "", "read command options from a file")
-	pflag.BoolP("quiet", "q", false, "set to only log errors")
-
-	pflag.Parse()
-
 	logLevel := os.Getenv("AWS_AUTO_ALARM_LOG_LEVEL")
 	switch logLevel {
 	case "debug":
@@ -29,16 +26,49 @@ func main() {
 		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
 	}
 
-	config := cli.NewConfig(ctx, pflag.CommandLine)
-	ctx = zerolog.Ctx(ctx).With().Str("arn", config.ParsedARN.String()).Logger().WithContext(ctx)
-	log := zerolog.Ctx(ctx)
+	os.Exit(cli.Execute(ctx, os.Args[1:], os.Stdout, os.Stderr, newClients))
+}
 
+func newClients(ctx context.Context, cfg *config.Config) (*cli.Clients, error) {
 	cw, err := awsclient.CloudWatch(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Send()
+		return nil, err
+	}
+	tag, err := awsclient.ResourcesTagAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ddb, err := awsclient.DynamoDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fn, err := awsclient.Lambda(ctx)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := awsclient.SQS(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := awsclient.S3(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = cli.New(config, cw, os.Stdout).Run(ctx); err != nil {
-		log.Fatal().Err(err).Send()
+	// the AWS_AUTO_ALARM_TEMPLATE_SOURCE variable is loaded into the config
+	templates, err := template.OpenSource(ctx, cfg.TemplateSource, objects)
+	if err != nil {
+		return nil, err
 	}
+
+	return &cli.Clients{
+		MetricAPI:   cw,
+		ResourceAPI: tag,
+		Resources: &resources.Clients{
+			DescribeTableAPI: ddb,
+			GetFunctionAPI:   fn,
+			QueueAPI:         queue,
+		},
+		Templates: templates,
+	}, nil
 }
@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"os"
+	"strconv"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/rs/zerolog"
 
 	"github.com/akijowski/aws-auto-alarm/internal/awsclient"
+	cmdcw "github.com/akijowski/aws-auto-alarm/internal/command/cloudwatch"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
 	"github.com/akijowski/aws-auto-alarm/internal/task"
+	"github.com/akijowski/aws-auto-alarm/internal/template"
 )
 
 func main() {
@@ -38,9 +42,57 @@ func main() {
 	if err != nil {
 		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to create Resources Tag client")
 	}
+	ddb, err := awsclient.DynamoDB(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to create DynamoDB client")
+	}
+	fn, err := awsclient.Lambda(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to create Lambda client")
+	}
+	queue, err := awsclient.SQS(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to create SQS client")
+	}
+	objects, err := awsclient.S3(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to create S3 client")
+	}
+	// templates are loaded once per cold start and reused across invocations
+	templates, err := template.OpenSource(ctx, os.Getenv(template.SourceEnvVar), objects)
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to load alarm templates")
+	}
+	// AWS_AUTO_ALARM_* variables, such as AWS_AUTO_ALARM_ALARMACTIONS, are the defaults for every resource
+	loader := config.NewLoader()
+	if err = loader.LoadEnv(); err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to load config from the environment")
+	}
+	defaults, err := loader.Config()
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Failed to load config from the environment")
+	}
+	zerolog.Ctx(ctx).Debug().Interface("sources", loader.Sources()).Msg("Loaded default config")
+	concurrency := task.DefaultConcurrency
+	if v := os.Getenv(task.ConcurrencyEnvVar); v != "" {
+		if concurrency, err = strconv.Atoi(v); err != nil || concurrency < 1 {
+			zerolog.Ctx(ctx).Fatal().Err(err).Str("value", v).Msgf("Invalid %s", task.ConcurrencyEnvVar)
+		}
+	}
+	putOptions, err := cmdcw.OptionsFromEnv(os.LookupEnv)
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Err(err).Msg("Invalid put options")
+	}
 	handler := &task.AlarmHandler{
 		MetricAPI:   cw,
 		ResourceAPI: tag,
+		TableAPI:    ddb,
+		FunctionAPI: fn,
+		QueueAPI:    queue,
+		Templates:   templates,
+		Concurrency: concurrency,
+		PutOptions:  putOptions,
+		Defaults:    defaults,
 	}
-	lambda.StartWithOptions(handler.Handle, lambda.WithContext(ctx))
+	lambda.StartWithOptions(handler.Route, lambda.WithContext(ctx))
 }
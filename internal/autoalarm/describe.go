@@ -0,0 +1,35 @@
+package autoalarm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// describeAlarmsBatchSize is the maximum number of alarm names accepted by a single DescribeAlarms call.
+const describeAlarmsBatchSize = 100
+
+// DescribeMetricAlarms returns the existing metric alarms with the given names, keyed by name.
+func DescribeMetricAlarms(ctx context.Context, api DescribeAlarmsAPI, names []string) (map[string]types.MetricAlarm, error) {
+	alarms := make(map[string]types.MetricAlarm)
+	for start := 0; start < len(names); start += describeAlarmsBatchSize {
+		end := min(start+describeAlarmsBatchSize, len(names))
+		paginator := cloudwatch.NewDescribeAlarmsPaginator(api, &cloudwatch.DescribeAlarmsInput{
+			AlarmNames: names[start:end],
+		})
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to describe alarms: %w", err)
+			}
+			for _, alarm := range out.MetricAlarms {
+				alarms[aws.ToString(alarm.AlarmName)] = alarm
+			}
+		}
+	}
+
+	return alarms, nil
+}
@@ -0,0 +1,36 @@
+package autoalarm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDescribeAlarmsAPI struct {
+	inputs []*cloudwatch.DescribeAlarmsInput
+}
+
+func (f *fakeDescribeAlarmsAPI) DescribeAlarms(_ context.Context, in *cloudwatch.DescribeAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	f.inputs = append(f.inputs, in)
+	return &cloudwatch.DescribeAlarmsOutput{}, nil
+}
+
+func TestDescribeMetricAlarms(t *testing.T) {
+	t.Parallel()
+
+	names := make([]string, 250)
+	for i := range names {
+		names[i] = "alarm"
+	}
+
+	api := new(fakeDescribeAlarmsAPI)
+	_, err := DescribeMetricAlarms(context.TODO(), api, names)
+	require.NoError(t, err)
+
+	require.Len(t, api.inputs, 3)
+	assert.Len(t, api.inputs[0].AlarmNames, 100)
+	assert.Len(t, api.inputs[2].AlarmNames, 50)
+}
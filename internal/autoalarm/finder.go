@@ -2,6 +2,8 @@ package autoalarm
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
@@ -12,6 +14,12 @@ import (
 // sample ARNs: arn:aws:cloudwatch:us-east-2:123456789012:alarm:this alarm-name has spaces and / other < characters > to handle
 // arn:aws:cloudwatch:us-east-2:123456789012:alarm:this-alarm-is-all-kebobs-and-numbers-like-123
 
+const alarmResourcePrefix = "alarm:"
+
+// SupportedServices are the services that have alarm templates.
+var SupportedServices = []string{"dynamodb", "events", "lambda", "sqs"}
+
+// NameFinder finds the names of alarms managed for a resource by querying the ownership tags applied to each alarm.
 type NameFinder struct {
 	api GetResourcesAPI
 	arn arn.ARN
@@ -24,8 +32,10 @@ func NewNameFinder(api GetResourcesAPI, arn arn.ARN) *NameFinder {
 	}
 }
 
+// Find pages through all CloudWatch alarms tagged as managed for the configured ARN and returns their names.
 func (f *NameFinder) Find(ctx context.Context) ([]string, error) {
 	input := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: []string{"cloudwatch:alarm"},
 		TagFilters: []types.TagFilter{
 			{
 				Key:    aws.String("AWS_AUTO_ALARM_MANAGED"),
@@ -38,19 +48,153 @@ func (f *NameFinder) Find(ctx context.Context) ([]string, error) {
 		},
 	}
 
-	output, err := f.api.GetResources(ctx, input)
-	if err != nil {
-		return nil, err
-	}
-
 	alarmNames := make([]string, 0)
-	for _, mapping := range output.ResourceTagMappingList {
-		alarmARN, err := arn.Parse(aws.ToString(mapping.ResourceARN))
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(f.api, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, err
 		}
-		alarmNames = append(alarmNames, alarmARN.Resource)
+
+		for _, mapping := range output.ResourceTagMappingList {
+			name, err := alarmName(mapping)
+			if err != nil {
+				return nil, err
+			}
+			alarmNames = append(alarmNames, name)
+		}
 	}
 
 	return alarmNames, nil
 }
+
+// ManagedAlarms pages through all CloudWatch alarms tagged as managed and returns their names grouped by the value of
+// their AWS_AUTO_ALARM_SOURCE_ARN tag.
+func ManagedAlarms(ctx context.Context, api GetResourcesAPI) (map[string][]string, error) {
+	return sourcedAlarms(ctx, api, nil, func(map[string]string) bool { return true })
+}
+
+// ExpiredMutes pages through the managed CloudWatch alarms tagged AWS_AUTO_ALARM_MUTED_UNTIL and returns the names of
+// the alarms whose mute ended at or before now, grouped by the value of their AWS_AUTO_ALARM_SOURCE_ARN tag. A mute time
+// that cannot be parsed counts as ended, so that the alarm is put again.
+func ExpiredMutes(ctx context.Context, api GetResourcesAPI, now time.Time) (map[string][]string, error) {
+	muted := types.TagFilter{Key: aws.String("AWS_AUTO_ALARM_MUTED_UNTIL")}
+
+	return sourcedAlarms(ctx, api, []types.TagFilter{muted}, func(tags map[string]string) bool {
+		until, err := time.Parse(time.RFC3339, tags["AWS_AUTO_ALARM_MUTED_UNTIL"])
+		return err != nil || !until.After(now)
+	})
+}
+
+// sourcedAlarms pages through the managed CloudWatch alarms that also have the tag filters and returns the names of
+// those that keep returns true for, grouped by the value of their AWS_AUTO_ALARM_SOURCE_ARN tag.
+func sourcedAlarms(ctx context.Context, api GetResourcesAPI, filters []types.TagFilter, keep func(tags map[string]string) bool) (map[string][]string, error) {
+	input := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: []string{"cloudwatch:alarm"},
+		TagFilters: append([]types.TagFilter{
+			{
+				Key:    aws.String("AWS_AUTO_ALARM_MANAGED"),
+				Values: []string{"true"},
+			},
+		}, filters...),
+	}
+
+	alarms := make(map[string][]string)
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(api, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mapping := range output.ResourceTagMappingList {
+			tags := TagMap(mapping.Tags)
+			if !keep(tags) {
+				continue
+			}
+
+			name, err := alarmName(mapping)
+			if err != nil {
+				return nil, err
+			}
+
+			source := tags["AWS_AUTO_ALARM_SOURCE_ARN"]
+			alarms[source] = append(alarms[source], name)
+		}
+	}
+
+	return alarms, nil
+}
+
+// FindResources pages through every resource that has all the tags and returns them with their tags.
+// The services, such as "sqs" or "lambda:function", limit the resource types that are returned. When there are no
+// services, every resource type is returned.
+func FindResources(ctx context.Context, api GetResourcesAPI, tags map[string]string, services []string) ([]types.ResourceTagMapping, error) {
+	input := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: services,
+	}
+	for key, value := range tags {
+		input.TagFilters = append(input.TagFilters, types.TagFilter{
+			Key:    aws.String(key),
+			Values: []string{value},
+		})
+	}
+
+	mappings := make([]types.ResourceTagMapping, 0)
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(api, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		mappings = append(mappings, output.ResourceTagMappingList...)
+	}
+
+	return mappings, nil
+}
+
+// maxResourceARNs is the GetResources limit on the number of ARNs in a ResourceARNList.
+const maxResourceARNs = 100
+
+// ResourcesByARN returns the resources with the ARNs, with their tags. Resources that do not exist or have no tags are
+// not returned.
+func ResourcesByARN(ctx context.Context, api GetResourcesAPI, arns []string) ([]types.ResourceTagMapping, error) {
+	mappings := make([]types.ResourceTagMapping, 0, len(arns))
+	for start := 0; start < len(arns); start += maxResourceARNs {
+		input := &resourcegroupstaggingapi.GetResourcesInput{
+			ResourceARNList: arns[start:min(start+maxResourceARNs, len(arns))],
+		}
+
+		paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(api, input)
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			mappings = append(mappings, output.ResourceTagMappingList...)
+		}
+	}
+
+	return mappings, nil
+}
+
+// TagMap returns the tags as a map of keys to values.
+func TagMap(tags []types.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		m[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return m
+}
+
+func alarmName(mapping types.ResourceTagMapping) (string, error) {
+	alarmARN, err := arn.Parse(aws.ToString(mapping.ResourceARN))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimPrefix(alarmARN.Resource, alarmResourcePrefix), nil
+}
@@ -0,0 +1,236 @@
+package autoalarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGetResourcesAPI struct {
+	pages  []*resourcegroupstaggingapi.GetResourcesOutput
+	err    error
+	inputs []*resourcegroupstaggingapi.GetResourcesInput
+}
+
+func (f *fakeGetResourcesAPI) GetResources(_ context.Context, in *resourcegroupstaggingapi.GetResourcesInput, _ ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	f.inputs = append(f.inputs, in)
+	if f.err != nil {
+		return nil, f.err
+	}
+	page := f.pages[len(f.inputs)-1]
+	return page, nil
+}
+
+func alarmMapping(name string) types.ResourceTagMapping {
+	return types.ResourceTagMapping{
+		ResourceARN: aws.String("arn:aws:cloudwatch:us-east-1:123456789012:alarm:" + name),
+	}
+}
+
+func TestNameFinder_Find(t *testing.T) {
+	t.Parallel()
+
+	queueARN := arn.ARN{
+		Partition: "aws",
+		Service:   "sqs",
+		Region:    "us-east-1",
+		AccountID: "123456789012",
+		Resource:  "test-queue",
+	}
+
+	t.Run("pages through all results", func(t *testing.T) {
+		t.Parallel()
+
+		api := &fakeGetResourcesAPI{
+			pages: []*resourcegroupstaggingapi.GetResourcesOutput{
+				{
+					PaginationToken: aws.String("next"),
+					ResourceTagMappingList: []types.ResourceTagMapping{
+						alarmMapping("first alarm"),
+						alarmMapping("second / alarm"),
+					},
+				},
+				{
+					PaginationToken: aws.String(""),
+					ResourceTagMappingList: []types.ResourceTagMapping{
+						alarmMapping("third-alarm"),
+					},
+				},
+			},
+		}
+
+		names, err := NewNameFinder(api, queueARN).Find(context.TODO())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"first alarm", "second / alarm", "third-alarm"}, names)
+		require.Len(t, api.inputs, 2)
+		assert.Equal(t, "next", aws.ToString(api.inputs[1].PaginationToken))
+		assert.Equal(t, queueARN.String(), api.inputs[0].TagFilters[1].Values[0])
+	})
+
+	t.Run("returns api errors", func(t *testing.T) {
+		t.Parallel()
+
+		api := &fakeGetResourcesAPI{err: errors.New("boom")}
+
+		_, err := NewNameFinder(api, queueARN).Find(context.TODO())
+		assert.Error(t, err)
+	})
+}
+
+func TestManagedAlarms(t *testing.T) {
+	t.Parallel()
+
+	sourced := func(name, source string) types.ResourceTagMapping {
+		mapping := alarmMapping(name)
+		mapping.Tags = []types.Tag{
+			{Key: aws.String("AWS_AUTO_ALARM_MANAGED"), Value: aws.String("true")},
+			{Key: aws.String("AWS_AUTO_ALARM_SOURCE_ARN"), Value: aws.String(source)},
+		}
+		return mapping
+	}
+
+	t.Run("groups alarms by source ARN", func(t *testing.T) {
+		t.Parallel()
+
+		api := &fakeGetResourcesAPI{
+			pages: []*resourcegroupstaggingapi.GetResourcesOutput{
+				{
+					PaginationToken: aws.String("next"),
+					ResourceTagMappingList: []types.ResourceTagMapping{
+						sourced("queue-a one", "arn:aws:sqs:us-east-1:123456789012:queue-a"),
+						sourced("queue-b one", "arn:aws:sqs:us-east-1:123456789012:queue-b"),
+					},
+				},
+				{
+					ResourceTagMappingList: []types.ResourceTagMapping{
+						sourced("queue-a two", "arn:aws:sqs:us-east-1:123456789012:queue-a"),
+					},
+				},
+			},
+		}
+
+		alarms, err := ManagedAlarms(context.TODO(), api)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string][]string{
+			"arn:aws:sqs:us-east-1:123456789012:queue-a": {"queue-a one", "queue-a two"},
+			"arn:aws:sqs:us-east-1:123456789012:queue-b": {"queue-b one"},
+		}, alarms)
+		require.Len(t, api.inputs, 2)
+		assert.Len(t, api.inputs[0].TagFilters, 1)
+	})
+
+	t.Run("returns api errors", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ManagedAlarms(context.TODO(), &fakeGetResourcesAPI{err: errors.New("boom")})
+		assert.Error(t, err)
+	})
+}
+
+func TestExpiredMutes(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC)
+	muted := func(name, until string) types.ResourceTagMapping {
+		mapping := alarmMapping(name)
+		mapping.Tags = []types.Tag{
+			{Key: aws.String("AWS_AUTO_ALARM_MANAGED"), Value: aws.String("true")},
+			{Key: aws.String("AWS_AUTO_ALARM_SOURCE_ARN"), Value: aws.String("arn:aws:sqs:us-east-1:123456789012:queue-a")},
+			{Key: aws.String("AWS_AUTO_ALARM_MUTED_UNTIL"), Value: aws.String(until)},
+		}
+		return mapping
+	}
+
+	api := &fakeGetResourcesAPI{
+		pages: []*resourcegroupstaggingapi.GetResourcesOutput{
+			{
+				ResourceTagMappingList: []types.ResourceTagMapping{
+					muted("ended", "2026-11-01T05:00:00Z"),
+					muted("ends now", "2026-11-01T06:00:00Z"),
+					muted("still muted", "2026-11-01T07:00:00Z"),
+					muted("invalid", "tomorrow"),
+				},
+			},
+		},
+	}
+
+	alarms, err := ExpiredMutes(context.TODO(), api, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"arn:aws:sqs:us-east-1:123456789012:queue-a": {"ended", "ends now", "invalid"},
+	}, alarms)
+	require.Len(t, api.inputs, 1)
+	assert.Equal(t, []string{"cloudwatch:alarm"}, api.inputs[0].ResourceTypeFilters)
+	assert.Equal(t, aws.String("AWS_AUTO_ALARM_MUTED_UNTIL"), api.inputs[0].TagFilters[1].Key)
+}
+
+func TestResourcesByARN(t *testing.T) {
+	t.Parallel()
+
+	arns := make([]string, maxResourceARNs+1)
+	for i := range arns {
+		arns[i] = fmt.Sprintf("arn:aws:sqs:us-east-1:123456789012:queue-%d", i)
+	}
+	queue := types.ResourceTagMapping{ResourceARN: aws.String(arns[0])}
+
+	api := &fakeGetResourcesAPI{
+		pages: []*resourcegroupstaggingapi.GetResourcesOutput{
+			{ResourceTagMappingList: []types.ResourceTagMapping{queue}},
+			{},
+		},
+	}
+
+	mappings, err := ResourcesByARN(context.TODO(), api, arns)
+	require.NoError(t, err)
+
+	assert.Equal(t, []types.ResourceTagMapping{queue}, mappings)
+	require.Len(t, api.inputs, 2)
+	assert.Len(t, api.inputs[0].ResourceARNList, maxResourceARNs)
+	assert.Equal(t, arns[maxResourceARNs:], api.inputs[1].ResourceARNList)
+}
+
+func TestFindResources(t *testing.T) {
+	t.Parallel()
+
+	queue := types.ResourceTagMapping{
+		ResourceARN: aws.String("arn:aws:sqs:us-east-1:123456789012:queue-a"),
+		Tags: []types.Tag{
+			{Key: aws.String("AWS_AUTO_ALARM_ENABLED"), Value: aws.String("true")},
+			{Key: aws.String("AWS_AUTO_ALARM_ALARMPREFIX"), Value: aws.String("team")},
+		},
+	}
+	function := types.ResourceTagMapping{
+		ResourceARN: aws.String("arn:aws:lambda:us-east-1:123456789012:function:my-function"),
+	}
+
+	api := &fakeGetResourcesAPI{
+		pages: []*resourcegroupstaggingapi.GetResourcesOutput{
+			{PaginationToken: aws.String("next"), ResourceTagMappingList: []types.ResourceTagMapping{queue}},
+			{ResourceTagMappingList: []types.ResourceTagMapping{function}},
+		},
+	}
+
+	mappings, err := FindResources(context.TODO(), api, map[string]string{"AWS_AUTO_ALARM_ENABLED": "true"}, []string{"sqs", "lambda:function"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []types.ResourceTagMapping{queue, function}, mappings)
+	require.Len(t, api.inputs, 2)
+	assert.Equal(t, []string{"sqs", "lambda:function"}, api.inputs[0].ResourceTypeFilters)
+	assert.Equal(t, []types.TagFilter{{Key: aws.String("AWS_AUTO_ALARM_ENABLED"), Values: []string{"true"}}}, api.inputs[0].TagFilters)
+	assert.Equal(t, map[string]string{
+		"AWS_AUTO_ALARM_ENABLED":     "true",
+		"AWS_AUTO_ALARM_ALARMPREFIX": "team",
+	}, TagMap(queue.Tags))
+}
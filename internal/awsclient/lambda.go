@@ -0,0 +1,18 @@
+package awsclient
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// Lambda returns a client used to read the configuration of Lambda functions.
+func Lambda(ctx context.Context) (*lambda.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return lambda.NewFromConfig(cfg), nil
+}
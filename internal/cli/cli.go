@@ -5,15 +5,20 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"slices"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
 	"github.com/akijowski/aws-auto-alarm/internal/command"
+	cmdcw "github.com/akijowski/aws-auto-alarm/internal/command/cloudwatch"
 	"github.com/akijowski/aws-auto-alarm/internal/config"
 	"github.com/akijowski/aws-auto-alarm/internal/template"
+	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
 )
 
 type DeleteCmdRegistry interface {
@@ -21,7 +26,7 @@ type DeleteCmdRegistry interface {
 }
 
 type CreateCmdRegistry interface {
-	CreateCommand(ctx context.Context, cmdType string, loader command.AlarmLoader) (autoalarm.Command, error)
+	CreateCommand(ctx context.Context, cmdType string, loader command.AlarmLoader, pruner command.AlarmNameFinder) (autoalarm.Command, error)
 }
 
 type CmdRegistry interface {
@@ -30,30 +35,59 @@ type CmdRegistry interface {
 }
 
 type CLI struct {
-	cfg  *config.Config
-	cmds CmdRegistry
+	cfg         *config.Config
+	cmds        CmdRegistry
+	resourceAPI autoalarm.GetResourcesAPI
+	clients     *resources.Clients
+	templates   fs.FS
+	wr          io.Writer
 }
 
-func New(cfg *config.Config, api autoalarm.MetricAlarmAPI, wr io.Writer) *CLI {
+// New returns a CLI for the config.Config. The templates fs.FS may be nil to use only the embedded templates.
+// The putOptions configure how alarms are put to CloudWatch, see cmdcw.OptionsFromEnv.
+func New(cfg *config.Config, api autoalarm.MetricAlarmAPI, resourceAPI autoalarm.GetResourcesAPI, clients *resources.Clients, templates fs.FS, wr io.Writer, putOptions ...func(*cmdcw.CreateOptions)) *CLI {
 	return &CLI{
-		cfg:  cfg,
-		cmds: command.DefaultRegistry(api, wr),
+		cfg:         cfg,
+		cmds:        command.DefaultRegistry(api, wr, putOptions...),
+		resourceAPI: resourceAPI,
+		clients:     clients,
+		templates:   templates,
+		wr:          wr,
 	}
 }
 
+// Run creates or deletes the alarms for the ARN in the config.Config, or for every discovered resource when the
+// config.Config has a config.Discover.
 func (c *CLI) Run(ctx context.Context) error {
 	log.Ctx(ctx).
 		Info().
 		Interface("config", c.cfg).
 		Msg("running cli")
-	cmdType := "cloudwatch"
-	if c.cfg.DryRun {
-		cmdType = "json"
+
+	if c.cfg.Discover != nil {
+		return c.runDiscovered(ctx)
 	}
 
-	cmd, err := c.cmds.CreateCommand(ctx, cmdType, template.NewFileLoader(ctx, c.cfg))
-	if c.cfg.Delete {
-		cmd, err = c.cmds.DeleteCommand(ctx, cmdType, template.NewFileFinder(ctx, c.cfg))
+	return c.run(ctx, c.cfg)
+}
+
+func (c *CLI) run(ctx context.Context, cfg *config.Config) error {
+	cmdType := command.CommandType(cfg)
+
+	mapper := resources.NewMapper(cfg, c.clients)
+
+	var cmd autoalarm.Command
+	var err error
+	if cfg.Delete {
+		var finder command.AlarmNameFinder
+		finder, err = command.NewAlarmNameFinder(ctx, cfg, c.resourceAPI, mapper, c.templates)
+		if err != nil {
+			return fmt.Errorf("unable to create alarm finder: %w", err)
+		}
+		cmd, err = c.cmds.DeleteCommand(ctx, cmdType, finder)
+	} else {
+		cmd, err = c.cmds.CreateCommand(ctx, cmdType, template.NewFileLoader(ctx, cfg, mapper, c.templates),
+			command.NewPruneFinder(cfg, c.resourceAPI))
 	}
 	if err != nil {
 		return fmt.Errorf("unable to create command: %w", err)
@@ -61,3 +95,22 @@ func (c *CLI) Run(ctx context.Context) error {
 
 	return cmd.Execute(ctx)
 }
+
+// List writes the names of the managed alarms in CloudWatch for the ARN, one per line.
+func (c *CLI) List(ctx context.Context) error {
+	if c.resourceAPI == nil {
+		return errors.New("a resources API is required to list alarms")
+	}
+
+	names, err := autoalarm.NewNameFinder(c.resourceAPI, c.cfg.ParsedARN).Find(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to find alarms: %w", err)
+	}
+
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintln(c.wr, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/pflag"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	cmdcw "github.com/akijowski/aws-auto-alarm/internal/command/cloudwatch"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+	"github.com/akijowski/aws-auto-alarm/internal/template"
+	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
+)
+
+// Exit codes returned by Execute.
+const (
+	ExitOK = 0
+	// ExitFailure means the subcommand ran and failed, such as with a CloudWatch API error.
+	ExitFailure = 1
+	// ExitUsage means the subcommand or its flags are not valid.
+	ExitUsage = 2
+	// ExitInvalid means the config, or the alarms that it renders, are not valid.
+	ExitInvalid = 3
+)
+
+// Clients holds the API clients and templates used by the subcommands.
+type Clients struct {
+	MetricAPI   autoalarm.MetricAlarmAPI
+	ResourceAPI autoalarm.GetResourcesAPI
+	Resources   *resources.Clients
+	// Templates holds external alarm templates. When nil, only the embedded templates are used.
+	Templates fs.FS
+}
+
+// ClientsFunc returns the Clients to run with for the config.Config.
+type ClientsFunc func(ctx context.Context, cfg *config.Config) (*Clients, error)
+
+type subcommand struct {
+	name        string
+	description string
+	// deleteFlag adds a --delete flag to select between the create and delete actions
+	deleteFlag bool
+	// outputFlag adds an --output flag to select the format of the rendered alarms
+	outputFlag bool
+	// singleARN rejects resource discovery
+	singleARN bool
+	// configure sets the action of the subcommand on the config.Config
+	configure func(pflags *pflag.FlagSet, cfg *config.Config)
+	run       func(c *CLI, ctx context.Context) error
+}
+
+var subcommands = []subcommand{
+	{
+		name:        "apply",
+		description: "create or update the alarms in CloudWatch and prune stale alarms",
+		configure: func(_ *pflag.FlagSet, cfg *config.Config) {
+			cfg.Delete, cfg.DryRun, cfg.Plan = false, false, false
+		},
+		run: (*CLI).Run,
+	},
+	{
+		name:        "delete",
+		description: "delete the alarms from CloudWatch",
+		configure: func(_ *pflag.FlagSet, cfg *config.Config) {
+			cfg.Delete, cfg.DryRun, cfg.Plan = true, false, false
+		},
+		run: (*CLI).Run,
+	},
+	{
+		name:        "render",
+		description: "write the alarms, or the alarm names to delete, without changing CloudWatch",
+		deleteFlag:  true,
+		outputFlag:  true,
+		configure: func(pflags *pflag.FlagSet, cfg *config.Config) {
+			cfg.Delete, _ = pflags.GetBool("delete")
+			cfg.DryRun, cfg.Plan = true, false
+		},
+		run: (*CLI).Run,
+	},
+	{
+		name:        "plan",
+		description: "show the changes that apply, or delete, would make to CloudWatch",
+		deleteFlag:  true,
+		configure: func(pflags *pflag.FlagSet, cfg *config.Config) {
+			cfg.Delete, _ = pflags.GetBool("delete")
+			cfg.Plan = true
+		},
+		run: (*CLI).Run,
+	},
+	{
+		name:        "list",
+		description: "list the managed alarms in CloudWatch for the ARN",
+		singleARN:   true,
+		run:         (*CLI).List,
+	},
+	{
+		name:        "validate",
+		description: "check the config and the alarms that it renders",
+		singleARN:   true,
+		run:         (*CLI).Validate,
+	},
+}
+
+// legacy runs the action chosen by the delete, dryRun and plan fields of the config file.
+var legacy = subcommand{
+	run: (*CLI).Run,
+}
+
+// Execute runs the subcommand in args and returns the exit code for the process.
+// When args do not start with a subcommand, the action is chosen by the config file.
+func Execute(ctx context.Context, args []string, stdout, stderr io.Writer, newClients ClientsFunc) int {
+	sub := legacy
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		found := false
+		for _, s := range subcommands {
+			if s.name == args[0] {
+				sub, found = s, true
+			}
+		}
+		if !found {
+			fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+			usage(stderr)
+			return ExitUsage
+		}
+		args = args[1:]
+	}
+
+	pflags := newFlagSet(sub, stderr)
+	if err := pflags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if pflags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(pflags.Args(), " "))
+		return ExitUsage
+	}
+
+	if quiet, _ := pflags.GetBool("quiet"); quiet {
+		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	}
+
+	logger := log.Ctx(ctx)
+	cfg, err := NewConfig(ctx, pflags)
+	if err != nil {
+		logger.Error().Err(err).Msg("invalid config")
+		return ExitInvalid
+	}
+	if sub.configure != nil {
+		sub.configure(pflags, cfg)
+	}
+
+	if cfg.Discover != nil && sub.singleARN {
+		fmt.Fprintf(stderr, "%s requires an ARN and does not discover resources\n", sub.name)
+		return ExitUsage
+	}
+	if cfg.Discover == nil {
+		ctx = logger.With().Str("arn", cfg.ParsedARN.String()).Logger().WithContext(ctx)
+		logger = log.Ctx(ctx)
+	}
+
+	putOptions, err := cmdcw.OptionsFromEnv(os.LookupEnv)
+	if err != nil {
+		logger.Error().Err(err).Msg("invalid config")
+		return ExitInvalid
+	}
+
+	clients, err := newClients(ctx, cfg)
+	if err != nil {
+		logger.Error().Err(err).Msg("unable to create clients")
+		return ExitFailure
+	}
+
+	c := New(cfg, clients.MetricAPI, clients.ResourceAPI, clients.Resources, clients.Templates, stdout, putOptions...)
+	if err = sub.run(c, ctx); err != nil {
+		logger.Error().Err(err).Send()
+
+		var vErr *ValidationError
+		var cErr *template.ConfigError
+		if errors.As(err, &vErr) || errors.As(err, &cErr) {
+			return ExitInvalid
+		}
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+func newFlagSet(sub subcommand, stderr io.Writer) *pflag.FlagSet {
+	pflags := pflag.NewFlagSet(sub.name, pflag.ContinueOnError)
+	pflags.SetOutput(stderr)
+
+	pflags.StringP("file", "f", "", "read command options from a file")
+	pflags.BoolP("quiet", "q", false, "set to only log errors")
+	pflags.String("arn", "", "the ARN of the resource to alarm, instead of the file value")
+	pflags.String("alarm-prefix", "", "the prefix for alarm names, instead of the file value")
+	pflags.StringSlice("alarm-action", nil, "an ARN to notify when an alarm goes into ALARM, instead of the file values")
+	pflags.StringSlice("ok-action", nil, "an ARN to notify when an alarm goes into OK, instead of the file values")
+	pflags.String("template-source", "", "a directory or s3://bucket/prefix to load templates from")
+	pflags.Bool("pretty", false, "pretty print JSON output")
+	if !sub.singleARN {
+		pflags.StringToString("tag", nil, "discover resources with the tag KEY=VALUE instead of using a single ARN")
+		pflags.StringSlice("service", nil, "limit discovered resources to the services, such as sqs or lambda:function")
+	}
+	if sub.deleteFlag {
+		pflags.Bool("delete", false, "use the delete action instead of create")
+	}
+	if sub.outputFlag {
+		pflags.String("output", config.OutputJSON, "the format of the alarms: json, cloudformation or terraform")
+	}
+
+	pflags.Usage = func() {
+		if sub.name == "" {
+			usage(stderr)
+		} else {
+			fmt.Fprintf(stderr, "Usage: aws_auto_alarm %s [flags]\n\n%s\n\nFlags:\n", sub.name, sub.description)
+		}
+		fmt.Fprint(stderr, pflags.FlagUsages())
+	}
+
+	return pflags
+}
+
+func usage(wr io.Writer) {
+	fmt.Fprint(wr, "Usage: aws_auto_alarm <command> [flags]\n\nCommands:\n")
+	for _, s := range subcommands {
+		fmt.Fprintf(wr, "  %-10s %s\n", s.name, s.description)
+	}
+	fmt.Fprint(wr, "\nWithout a command, the action is chosen by the delete, dryRun and plan fields of the config file.\n\n")
+}
@@ -2,44 +2,85 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"os"
 
+	"github.com/knadh/koanf/providers/posflag"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
 
 	"github.com/akijowski/aws-auto-alarm/internal/config"
 )
 
-func NewConfig(ctx context.Context, pflags *pflag.FlagSet) *config.Config {
+// flagKeys are the config.Config keys that are set by each flag.
+var flagKeys = map[string]string{
+	"arn":             "arn",
+	"alarm-prefix":    "alarmPrefix",
+	"alarm-action":    "alarmActions",
+	"ok-action":       "okActions",
+	"template-source": "templateSource",
+	"pretty":          "prettyPrint",
+	"output":          "output",
+	"tag":             "discover.tags",
+	"service":         "discover.services",
+}
+
+// NewConfig loads the config.Config from the built-in defaults, the file flag when it is set, the AWS_AUTO_ALARM_*
+// environment variables and then the flags that were set on the command line, in that order. The ARN is required
+// unless resources are discovered.
+func NewConfig(ctx context.Context, pflags *pflag.FlagSet) (*config.Config, error) {
 	logger := log.Ctx(ctx)
 
-	cfg := new(config.Config)
+	loader := config.NewLoader()
 
 	filePath, err := pflags.GetString("file")
 	if err != nil {
-		logger.Fatal().Err(fmt.Errorf("the flag file was not set: %w", err)).Send()
+		return nil, fmt.Errorf("the flag file was not set: %w", err)
 	}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		logger.Fatal().Err(err).Send()
+	if filePath != "" {
+		logger.Debug().Str("file", filePath).Msg("reading config file")
+		if err = loader.LoadFile(filePath); err != nil {
+			return nil, err
+		}
 	}
 
-	b, err := io.ReadAll(file)
+	if err = loader.LoadEnv(); err != nil {
+		return nil, err
+	}
+
+	flags := posflag.ProviderWithFlag(pflags, ".", nil, func(f *pflag.Flag) (string, any) {
+		key, ok := flagKeys[f.Name]
+		if !ok || !f.Changed {
+			return "", nil
+		}
+		return key, posflag.FlagVal(pflags, f)
+	})
+	if err = loader.Load(config.LayerFlags, flags, nil); err != nil {
+		return nil, err
+	}
+
+	cfg, err := loader.Config()
 	if err != nil {
-		logger.Fatal().Err(err).Send()
+		return nil, err
+	}
+	logger.Debug().Interface("sources", loader.Sources()).Msg("config loaded")
+
+	if err = config.ResolveRoutes(cfg); err != nil {
+		return nil, err
+	}
+
+	if err = config.ValidOutput(cfg.Output); err != nil {
+		return nil, err
 	}
 
-	if err = json.Unmarshal(b, &cfg); err != nil {
-		logger.Fatal().Err(err).Send()
+	// discovered resources are each given their own ARN
+	if cfg.Discover != nil {
+		return cfg, nil
 	}
 
 	if err = config.ParseARN(cfg); err != nil {
-		logger.Fatal().Err(err).Send()
+		return nil, err
 	}
 
-	return cfg
+	return cfg, nil
 }
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/rs/zerolog/log"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/command"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+// result is the outcome of a run for a single discovered resource.
+type result struct {
+	arn    string
+	action string
+	mode   string
+	err    error
+	// skipped is the reason that the resource was not run, such as a service without alarm templates.
+	skipped string
+}
+
+// runDiscovered finds the resources with the tags in the config.Discover and runs for each of them. Each resource is
+// configured from the config.Config and then its own AWS_AUTO_ALARM_* tags. A failure for one resource does not stop
+// the others, and a table of the results is written at the end. Resources of services that are not
+// autoalarm.SupportedServices are skipped and listed in the table.
+func (c *CLI) runDiscovered(ctx context.Context) error {
+	if c.resourceAPI == nil {
+		return errors.New("a resources API is required to discover resources")
+	}
+
+	mappings, err := autoalarm.FindResources(ctx, c.resourceAPI, c.cfg.Discover.Tags, c.cfg.Discover.Services)
+	if err != nil {
+		return fmt.Errorf("unable to discover resources: %w", err)
+	}
+	log.Ctx(ctx).Info().Int("resources_count", len(mappings)).Msg("discovered resources")
+
+	results := make([]result, 0, len(mappings))
+	failed := 0
+	for _, mapping := range mappings {
+		resourceARN := aws.ToString(mapping.ResourceARN)
+		logger := log.Ctx(ctx).With().Str("arn", resourceARN).Logger()
+
+		res := result{arn: resourceARN, action: "upsert", mode: command.CommandType(c.cfg)}
+		if c.cfg.Delete {
+			res.action = "delete"
+		}
+
+		if parsed, err := arn.Parse(resourceARN); err == nil && !slices.Contains(autoalarm.SupportedServices, parsed.Service) {
+			logger.Info().Str("service", parsed.Service).Msg("skipping unsupported resource service")
+			res.action = "skip"
+			res.skipped = fmt.Sprintf("service %s is not supported", parsed.Service)
+			results = append(results, res)
+			continue
+		}
+
+		cfg, err := resourceConfig(c.cfg, resourceARN, autoalarm.TagMap(mapping.Tags))
+		if err == nil {
+			res.mode = command.CommandType(cfg)
+			err = c.run(logger.WithContext(ctx), cfg)
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to run for resource")
+			res.err = err
+			failed++
+		}
+		results = append(results, res)
+	}
+
+	if err = writeResults(c.wr, results); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d resources failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// resourceConfig returns a copy of the base config.Config for the resource, updated from the resource tags.
+func resourceConfig(base *config.Config, resourceARN string, tags map[string]string) (*config.Config, error) {
+	cfg := base.Clone()
+	cfg.Discover = nil
+	cfg.ARN = resourceARN
+
+	if err := config.ParseARN(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := config.ApplyTags(cfg, tags); err != nil {
+		return nil, err
+	}
+
+	if err := config.ResolveRoutes(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func writeResults(wr io.Writer, results []result) error {
+	tw := tabwriter.NewWriter(wr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESOURCE\tACTION\tMODE\tRESULT")
+	for _, res := range results {
+		status := "ok"
+		switch {
+		case res.err != nil:
+			status = fmt.Sprintf("error: %s", res.err)
+		case res.skipped != "":
+			status = fmt.Sprintf("skipped: %s", res.skipped)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", res.arn, res.action, res.mode, status)
+	}
+
+	return tw.Flush()
+}
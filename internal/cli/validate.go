@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+	"github.com/akijowski/aws-auto-alarm/internal/template"
+	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
+)
+
+// ValidationError lists the problems found with a config and the alarms that it renders.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config: %s", strings.Join(e.Problems, "; "))
+}
+
+// Validate renders the alarms for the config.Config without sending them to CloudWatch and returns a
+// *ValidationError if the config or any alarm is not valid.
+func (c *CLI) Validate(ctx context.Context) error {
+	problems := validateConfig(c.cfg)
+
+	alarms, err := template.NewFileLoader(ctx, c.cfg, resources.NewMapper(c.cfg, c.clients), c.templates).Load(ctx)
+	var cErr *template.ConfigError
+	if errors.As(err, &cErr) {
+		problems = append(problems, cErr.Problems...)
+	} else if err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, alarm := range alarms {
+		problems = append(problems, validateAlarm(alarm)...)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	fmt.Fprintf(c.wr, "%d alarms are valid for %s\n", len(alarms), c.cfg.ARN)
+	return nil
+}
+
+func validateConfig(cfg *config.Config) []string {
+	problems := make([]string, 0)
+
+	switch cfg.DeleteStrategy {
+	case "", config.DeleteStrategyTags, config.DeleteStrategyTemplate:
+	default:
+		problems = append(problems, fmt.Sprintf("unsupported delete strategy %q", cfg.DeleteStrategy))
+	}
+
+	if err := config.ValidOutput(cfg.Output); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	actions := slices.Concat(cfg.AlarmActions, cfg.OKActions, cfg.InsufficientDataActions)
+	for _, route := range cfg.SeverityActions {
+		actions = slices.Concat(actions, route.AlarmActions, route.OKActions, route.InsufficientDataActions)
+	}
+	for _, action := range actions {
+		if _, err := arn.Parse(action); err != nil {
+			problems = append(problems, fmt.Sprintf("action %q is not an ARN", action))
+		}
+	}
+
+	return problems
+}
+
+func validateAlarm(alarm *cloudwatch.PutMetricAlarmInput) []string {
+	name := aws.ToString(alarm.AlarmName)
+	if name == "" {
+		return []string{"an alarm has no AlarmName"}
+	}
+
+	problems := make([]string, 0)
+	if alarm.ComparisonOperator == "" {
+		problems = append(problems, fmt.Sprintf("alarm %q has no ComparisonOperator", name))
+	}
+	if alarm.EvaluationPeriods == nil {
+		problems = append(problems, fmt.Sprintf("alarm %q has no EvaluationPeriods", name))
+	}
+	if aws.ToString(alarm.MetricName) == "" && len(alarm.Metrics) == 0 {
+		problems = append(problems, fmt.Sprintf("alarm %q has no MetricName or Metrics", name))
+	}
+
+	return problems
+}
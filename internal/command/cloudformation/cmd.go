@@ -0,0 +1,150 @@
+// Package cloudformation writes rendered alarms as a CloudFormation template of AWS::CloudWatch::Alarm resources.
+package cloudformation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	templateFormatVersion = "2010-09-09"
+	alarmResourceType     = "AWS::CloudWatch::Alarm"
+	// maxLogicalIDLength is the CloudFormation limit on the length of a logical ID.
+	maxLogicalIDLength = 255
+	hashLength         = 8
+)
+
+type template struct {
+	AWSTemplateFormatVersion string              `json:"AWSTemplateFormatVersion"`
+	Description              string              `json:"Description"`
+	Resources                map[string]resource `json:"Resources"`
+}
+
+type resource struct {
+	Type       string         `json:"Type"`
+	Properties map[string]any `json:"Properties"`
+}
+
+type CreateCmd struct {
+	inputs []*cloudwatch.PutMetricAlarmInput
+	wr     io.Writer
+}
+
+func NewCreateCmd(inputs []*cloudwatch.PutMetricAlarmInput, wr io.Writer) *CreateCmd {
+	return &CreateCmd{
+		inputs: inputs,
+		wr:     wr,
+	}
+}
+
+// Execute writes a CloudFormation template with an AWS::CloudWatch::Alarm resource for each alarm. See LogicalID.
+func (c *CreateCmd) Execute(ctx context.Context) error {
+	logger := log.Ctx(ctx)
+	logger.Debug().Msg("writing output as a CloudFormation template")
+
+	t := template{
+		AWSTemplateFormatVersion: templateFormatVersion,
+		Description:              "CloudWatch alarms generated by aws-auto-alarm",
+		Resources:                make(map[string]resource, len(c.inputs)),
+	}
+	for _, in := range c.inputs {
+		props, err := properties(in)
+		if err != nil {
+			return err
+		}
+		t.Resources[LogicalID(aws.ToString(in.AlarmName))] = resource{Type: alarmResourceType, Properties: props}
+	}
+
+	encoder := json.NewEncoder(c.wr)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(t)
+}
+
+// LogicalID returns the logical ID of the resource for an alarm name. It is the letters and digits of the name,
+// followed by a short hash of the full name, so the same alarm always has the same ID and names that only differ by
+// other characters, such as > and <, do not collide.
+func LogicalID(alarmName string) string {
+	sum := sha256.Sum256([]byte(alarmName))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+
+	var b strings.Builder
+	for _, r := range alarmName {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	id := b.String()
+	if len(id) > maxLogicalIDLength-hashLength {
+		id = id[:maxLogicalIDLength-hashLength]
+	}
+
+	return id + hash
+}
+
+// properties returns the resource properties of an alarm. The cloudwatch.PutMetricAlarmInput fields have the same names
+// as the AWS::CloudWatch::Alarm properties, so unset fields are dropped and the rest are kept as they are.
+func properties(in *cloudwatch.PutMetricAlarmInput) (map[string]any, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal alarm %s: %w", aws.ToString(in.AlarmName), err)
+	}
+
+	props := make(map[string]any)
+	if err = json.Unmarshal(b, &props); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal alarm %s: %w", aws.ToString(in.AlarmName), err)
+	}
+
+	return prune(props).(map[string]any), nil
+}
+
+// prune removes the null values, and the empty strings, lists and objects, from v.
+func prune(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if value = prune(value); empty(value) {
+				delete(v, key)
+			} else {
+				v[key] = value
+			}
+		}
+		return v
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, value := range v {
+			if value = prune(value); !empty(value) {
+				out = append(out, value)
+			}
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func empty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
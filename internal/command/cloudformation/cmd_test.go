@@ -0,0 +1,74 @@
+package cloudformation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCmd_Execute(t *testing.T) {
+	t.Parallel()
+
+	inputs := []*cloudwatch.PutMetricAlarmInput{
+		{
+			AlarmName:          aws.String("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue"),
+			ActionsEnabled:     aws.Bool(false),
+			ComparisonOperator: types.ComparisonOperatorGreaterThanThreshold,
+			EvaluationPeriods:  aws.Int32(15),
+			MetricName:         aws.String("ApproximateNumberOfMessagesVisible"),
+			Namespace:          aws.String("AWS/SQS"),
+			Period:             aws.Int32(60),
+			Statistic:          types.StatisticSum,
+			Threshold:          aws.Float64(0),
+			Dimensions:         []types.Dimension{{Name: aws.String("QueueName"), Value: aws.String("my-queue")}},
+			Tags:               []types.Tag{{Key: aws.String("AWS_AUTO_ALARM_MANAGED"), Value: aws.String("true")}},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, NewCreateCmd(inputs, buf).Execute(context.TODO()))
+
+	var got template
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	assert.Equal(t, templateFormatVersion, got.AWSTemplateFormatVersion)
+	require.Len(t, got.Resources, 1)
+
+	res, ok := got.Resources[LogicalID("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue")]
+	require.True(t, ok)
+	assert.Equal(t, alarmResourceType, res.Type)
+	assert.Equal(t, map[string]any{
+		"AlarmName":          "AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue",
+		"ActionsEnabled":     false,
+		"ComparisonOperator": "GreaterThanThreshold",
+		"EvaluationPeriods":  float64(15),
+		"MetricName":         "ApproximateNumberOfMessagesVisible",
+		"Namespace":          "AWS/SQS",
+		"Period":             float64(60),
+		"Statistic":          "Sum",
+		"Threshold":          float64(0),
+		"Dimensions":         []any{map[string]any{"Name": "QueueName", "Value": "my-queue"}},
+		"Tags":               []any{map[string]any{"Key": "AWS_AUTO_ALARM_MANAGED", "Value": "true"}},
+	}, res.Properties)
+	assert.Contains(t, buf.String(), `"AlarmName": "AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue"`)
+}
+
+func TestLogicalID(t *testing.T) {
+	t.Parallel()
+
+	id := LogicalID("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue")
+
+	assert.Regexp(t, `^AWSSQSApproximateNumberOfMessagesVisible100QueueNamemyqueue[0-9a-f]{8}$`, id)
+	assert.Equal(t, id, LogicalID("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue"))
+	assert.NotEqual(t, id, LogicalID("AWS/SQS ApproximateNumberOfMessagesVisible < 100 QueueName=my-queue"))
+
+	long := LogicalID(string(bytes.Repeat([]byte("a"), 300)))
+	assert.Len(t, long, maxLogicalIDLength)
+}
@@ -2,37 +2,304 @@ package cloudwatch
 
 import (
 	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
 
 	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
 )
 
+// PutMetricAlarm is limited to a low number of transactions per second for each account and region, so alarms are put
+// by a bounded number of workers that share a rate limit below the quota. The tag calls have their own, higher quotas,
+// so they have a separate rate limit. Both limits allow a burst of one call for each worker.
+const (
+	defaultConcurrency          = 3
+	defaultRequestsPerSecond    = 2
+	defaultTagRequestsPerSecond = 5
+	defaultBurst                = defaultConcurrency
+	defaultMaxAttempts          = 5
+	defaultBaseDelay            = 250 * time.Millisecond
+	defaultMaxDelay             = 5 * time.Second
+)
+
+// managedTags mark an alarm as managed and name its resource. They are never removed from an alarm.
+var managedTags = []string{"AWS_AUTO_ALARM_MANAGED", "AWS_AUTO_ALARM_SOURCE_ARN"}
+
+// defaultLimiter and defaultTagLimiter are shared by every CreateCmd in the process, so that concurrent commands stay
+// under the quotas together.
+var (
+	defaultLimiter    = rate.NewLimiter(defaultRequestsPerSecond, defaultBurst)
+	defaultTagLimiter = rate.NewLimiter(defaultTagRequestsPerSecond, defaultBurst)
+)
+
+// CreateOptions configure how a CreateCmd puts alarms.
+type CreateOptions struct {
+	// Concurrency is the number of PutMetricAlarm calls made at once.
+	Concurrency int
+	// Limiter limits the rate of all PutMetricAlarm calls, including retries.
+	Limiter *rate.Limiter
+	// TagLimiter limits the rate of all ListTagsForResource, TagResource and UntagResource calls, including retries.
+	TagLimiter *rate.Limiter
+	// MaxAttempts is the number of times an alarm is put before a throttling error is returned.
+	MaxAttempts int
+	// BaseDelay and MaxDelay bound the jittered exponential backoff between throttled attempts.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
 type CreateCmd struct {
-	inputs []*cloudwatch.PutMetricAlarmInput
-	api    autoalarm.PutMetricAlarmAPI
+	inputs  []*cloudwatch.PutMetricAlarmInput
+	api     autoalarm.UpsertAlarmAPI
+	options CreateOptions
 }
 
-func NewCreateCmd(inputs []*cloudwatch.PutMetricAlarmInput, api autoalarm.PutMetricAlarmAPI) *CreateCmd {
+func NewCreateCmd(inputs []*cloudwatch.PutMetricAlarmInput, api autoalarm.UpsertAlarmAPI, optFns ...func(*CreateOptions)) *CreateCmd {
+	options := CreateOptions{
+		Concurrency: defaultConcurrency,
+		Limiter:     defaultLimiter,
+		TagLimiter:  defaultTagLimiter,
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		MaxDelay:    defaultMaxDelay,
+	}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	options.Concurrency = max(options.Concurrency, 1)
+	options.MaxAttempts = max(options.MaxAttempts, 1)
+
 	return &CreateCmd{
-		inputs: inputs,
-		api:    api,
+		inputs:  inputs,
+		api:     api,
+		options: options,
 	}
 }
 
+// Execute puts every alarm, even when some of them fail, and returns a *PutAlarmsError listing the alarms that failed
+// and succeeded. The tags of alarms that already existed are converged after they are put, see syncTags.
 func (c *CreateCmd) Execute(ctx context.Context) error {
 	logger := log.Ctx(ctx)
-	logger.Debug().Msg("writing output to Cloudwatch")
+	logger.Debug().Int("alarms_count", len(c.inputs)).Msg("writing output to Cloudwatch")
+
+	names := make([]string, 0, len(c.inputs))
 	for _, in := range c.inputs {
+		names = append(names, aws.ToString(in.AlarmName))
+	}
+	existing, err := autoalarm.DescribeMetricAlarms(ctx, c.api, names)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(c.inputs))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(c.options.Concurrency, len(c.inputs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				errs[i] = c.put(ctx, c.inputs[i])
+				if alarm, ok := existing[names[i]]; ok && errs[i] == nil {
+					errs[i] = c.syncTags(ctx, aws.ToString(alarm.AlarmArn), c.inputs[i].Tags)
+				}
+			}
+		}()
+	}
+
+	for i := range c.inputs {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	result := &PutAlarmsError{Failed: make(map[string]error)}
+	for i, in := range c.inputs {
+		name := aws.ToString(in.AlarmName)
+		if errs[i] != nil {
+			result.Failed[name] = errs[i]
+		} else {
+			result.Succeeded = append(result.Succeeded, name)
+		}
+	}
+	if len(result.Failed) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+// put puts a single alarm, retrying throttling errors with jittered exponential backoff.
+func (c *CreateCmd) put(ctx context.Context, in *cloudwatch.PutMetricAlarmInput) error {
+	return c.retry(ctx, c.options.Limiter, "PutMetricAlarm", aws.ToString(in.AlarmName), func() error {
 		_, err := c.api.PutMetricAlarm(ctx, in)
+		return err
+	})
+}
+
+// syncTags tags the alarm with the rendered tags that it does not have, and removes the tags that were not rendered.
+// The managedTags are never removed.
+func (c *CreateCmd) syncTags(ctx context.Context, alarmARN string, tags []types.Tag) error {
+	var current []types.Tag
+	err := c.retry(ctx, c.options.TagLimiter, "ListTagsForResource", alarmARN, func() error {
+		out, err := c.api.ListTagsForResource(ctx, &cloudwatch.ListTagsForResourceInput{ResourceARN: aws.String(alarmARN)})
+		if err == nil {
+			current = out.Tags
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	add, remove := tagChanges(current, tags)
+	if len(add) > 0 {
+		err = c.retry(ctx, c.options.TagLimiter, "TagResource", alarmARN, func() error {
+			_, err := c.api.TagResource(ctx, &cloudwatch.TagResourceInput{ResourceARN: aws.String(alarmARN), Tags: add})
+			return err
+		})
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	if len(remove) > 0 {
+		err = c.retry(ctx, c.options.TagLimiter, "UntagResource", alarmARN, func() error {
+			_, err := c.api.UntagResource(ctx, &cloudwatch.UntagResourceInput{ResourceARN: aws.String(alarmARN), TagKeys: remove})
+			return err
+		})
+	}
+
+	return err
 }
 
+// tagChanges returns the tags to add or update, and the keys of the tags to remove, to change current into want.
+func tagChanges(current, want []types.Tag) ([]types.Tag, []string) {
+	have := make(map[string]string, len(current))
+	for _, tag := range current {
+		have[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	wanted := make(map[string]bool, len(want))
+
+	add := make([]types.Tag, 0)
+	for _, tag := range want {
+		key := aws.ToString(tag.Key)
+		wanted[key] = true
+		if value, ok := have[key]; !ok || value != aws.ToString(tag.Value) {
+			add = append(add, tag)
+		}
+	}
+
+	remove := make([]string, 0)
+	for _, tag := range current {
+		key := aws.ToString(tag.Key)
+		if !wanted[key] && !slices.Contains(managedTags, key) {
+			remove = append(remove, key)
+		}
+	}
+
+	return add, remove
+}
+
+// retry calls fn until it does not return a throttling error, with jittered exponential backoff, up to MaxAttempts
+// times. Each attempt waits for the limiter of the operation. The operation and the alarm name or ARN are logged with
+// each retry.
+func (c *CreateCmd) retry(ctx context.Context, limiter *rate.Limiter, operation, alarm string, fn func() error) error {
+	var err error
+	for attempt := range c.options.MaxAttempts {
+		if attempt > 0 {
+			delay := backoff(attempt, c.options.BaseDelay, c.options.MaxDelay)
+			log.Ctx(ctx).Debug().Str("alarm", alarm).Dur("delay", delay).
+				Err(err).Msgf("%s throttled, retrying", operation)
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		err = fn()
+		if err == nil || !isThrottle(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// backoff returns a random delay up to the exponential backoff for the attempt, capped at maxDelay.
+func backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	ceiling := min(base<<(attempt-1), maxDelay)
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return rand.N(ceiling) + 1
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isThrottle(err error) bool {
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
+
+// PutAlarmsError is returned by CreateCmd when any alarm could not be put.
+type PutAlarmsError struct {
+	// Failed holds the error for each alarm name that could not be put.
+	Failed map[string]error
+	// Succeeded holds the names of the alarms that were put.
+	Succeeded []string
+}
+
+func (e *PutAlarmsError) Error() string {
+	failed := make([]string, 0, len(e.Failed))
+	for name, err := range e.Failed {
+		failed = append(failed, fmt.Sprintf("%q: %s", name, err))
+	}
+	slices.Sort(failed)
+
+	succeeded := make([]string, 0, len(e.Succeeded))
+	for _, name := range e.Succeeded {
+		succeeded = append(succeeded, strconv.Quote(name))
+	}
+
+	return fmt.Sprintf("failed to put %d of %d alarms: [%s]; succeeded: [%s]",
+		len(e.Failed), len(e.Failed)+len(e.Succeeded), strings.Join(failed, ", "), strings.Join(succeeded, ", "))
+}
+
+// Unwrap returns the errors of the failed alarms, so that they can be inspected with errors.Is and errors.As.
+func (e *PutAlarmsError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// deleteAlarmsBatchSize is the maximum number of alarm names accepted by a single DeleteAlarms call.
+const deleteAlarmsBatchSize = 100
+
 type DeleteCmd struct {
 	input *cloudwatch.DeleteAlarmsInput
 	api   autoalarm.DeleteAlarmsAPI
@@ -45,12 +312,17 @@ func NewDeleteCmd(input *cloudwatch.DeleteAlarmsInput, api autoalarm.DeleteAlarm
 	}
 }
 
+// Execute deletes the alarms in batches of deleteAlarmsBatchSize.
 func (d *DeleteCmd) Execute(ctx context.Context) error {
 	logger := log.Ctx(ctx)
-	logger.Debug().Msg("writing output to Cloudwatch")
-	_, err := d.api.DeleteAlarms(ctx, d.input)
-	if err != nil {
-		return err
+	logger.Debug().Int("alarms_count", len(d.input.AlarmNames)).Msg("writing output to Cloudwatch")
+	names := d.input.AlarmNames
+	for start := 0; start < len(names); start += deleteAlarmsBatchSize {
+		end := min(start+deleteAlarmsBatchSize, len(names))
+		_, err := d.api.DeleteAlarms(ctx, &cloudwatch.DeleteAlarmsInput{AlarmNames: names[start:end]})
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
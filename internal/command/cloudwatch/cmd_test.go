@@ -0,0 +1,345 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// fakePutMetricAlarmAPI throttles each alarm name the configured number of times before it succeeds, and always fails
+// alarm names with a configured error. Alarms with tags already exist, and their tags are changed by tag calls.
+type fakePutMetricAlarmAPI struct {
+	mu        sync.Mutex
+	throttles map[string]int
+	errs      map[string]error
+	calls     map[string]int
+	inFlight  int
+	maxCalls  int
+	tags      map[string][]types.Tag
+	tagCalls  []string
+}
+
+func (f *fakePutMetricAlarmAPI) PutMetricAlarm(_ context.Context, in *cloudwatch.PutMetricAlarmInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	name := aws.ToString(in.AlarmName)
+
+	f.mu.Lock()
+	f.inFlight++
+	f.maxCalls = max(f.maxCalls, f.inFlight)
+	f.calls[name]++
+	calls := f.calls[name]
+	f.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inFlight--
+	if err, ok := f.errs[name]; ok {
+		return nil, err
+	}
+	if calls <= f.throttles[name] {
+		return nil, &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded", Fault: smithy.FaultClient}
+	}
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (f *fakePutMetricAlarmAPI) DescribeAlarms(_ context.Context, in *cloudwatch.DescribeAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	out := new(cloudwatch.DescribeAlarmsOutput)
+	for _, name := range in.AlarmNames {
+		if _, ok := f.tags[alarmARN(name)]; ok {
+			out.MetricAlarms = append(out.MetricAlarms, types.MetricAlarm{AlarmName: aws.String(name), AlarmArn: aws.String(alarmARN(name))})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakePutMetricAlarmAPI) ListTagsForResource(_ context.Context, in *cloudwatch.ListTagsForResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.ListTagsForResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &cloudwatch.ListTagsForResourceOutput{Tags: f.tags[aws.ToString(in.ResourceARN)]}, nil
+}
+
+func (f *fakePutMetricAlarmAPI) TagResource(_ context.Context, in *cloudwatch.TagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.TagResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	arn := aws.ToString(in.ResourceARN)
+	for _, tag := range in.Tags {
+		f.tagCalls = append(f.tagCalls, fmt.Sprintf("tag %s=%s", aws.ToString(tag.Key), aws.ToString(tag.Value)))
+		f.tags[arn] = slices.DeleteFunc(f.tags[arn], func(t types.Tag) bool { return aws.ToString(t.Key) == aws.ToString(tag.Key) })
+		f.tags[arn] = append(f.tags[arn], tag)
+	}
+	return &cloudwatch.TagResourceOutput{}, nil
+}
+
+func (f *fakePutMetricAlarmAPI) UntagResource(_ context.Context, in *cloudwatch.UntagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.UntagResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	arn := aws.ToString(in.ResourceARN)
+	for _, key := range in.TagKeys {
+		f.tagCalls = append(f.tagCalls, "untag "+key)
+		f.tags[arn] = slices.DeleteFunc(f.tags[arn], func(t types.Tag) bool { return aws.ToString(t.Key) == key })
+	}
+	return &cloudwatch.UntagResourceOutput{}, nil
+}
+
+func alarmARN(name string) string {
+	return "arn:aws:cloudwatch:us-east-1:123456789012:alarm:" + name
+}
+
+func tag(key, value string) types.Tag {
+	return types.Tag{Key: aws.String(key), Value: aws.String(value)}
+}
+
+func alarmInputs(names []string) []*cloudwatch.PutMetricAlarmInput {
+	inputs := make([]*cloudwatch.PutMetricAlarmInput, len(names))
+	for i, name := range names {
+		inputs[i] = &cloudwatch.PutMetricAlarmInput{AlarmName: aws.String(name)}
+	}
+	return inputs
+}
+
+func testOptions(o *CreateOptions) {
+	o.Concurrency = 2
+	o.Limiter = rate.NewLimiter(rate.Inf, 1)
+	o.TagLimiter = rate.NewLimiter(rate.Inf, 1)
+	o.MaxAttempts = 3
+	o.BaseDelay = time.Millisecond
+	o.MaxDelay = 2 * time.Millisecond
+}
+
+func TestCreateCmd_Execute(t *testing.T) {
+	t.Parallel()
+
+	validation := &smithy.GenericAPIError{Code: "ValidationError", Fault: smithy.FaultClient}
+
+	cases := map[string]struct {
+		throttles     map[string]int
+		errs          map[string]error
+		wantFailed    []string
+		wantSucceeded []string
+		wantCalls     map[string]int
+	}{
+		"all alarms succeed": {
+			wantCalls: map[string]int{"alarm-0": 1, "alarm-1": 1, "alarm-2": 1, "alarm-3": 1},
+		},
+		"throttled alarms are retried": {
+			throttles: map[string]int{"alarm-1": 2, "alarm-3": 1},
+			wantCalls: map[string]int{"alarm-0": 1, "alarm-1": 3, "alarm-2": 1, "alarm-3": 2},
+		},
+		"alarms throttled past the max attempts fail": {
+			throttles:     map[string]int{"alarm-2": 5},
+			wantFailed:    []string{"alarm-2"},
+			wantSucceeded: []string{"alarm-0", "alarm-1", "alarm-3"},
+			wantCalls:     map[string]int{"alarm-0": 1, "alarm-1": 1, "alarm-2": 3, "alarm-3": 1},
+		},
+		"other errors are not retried and do not stop other alarms": {
+			errs:          map[string]error{"alarm-0": validation},
+			wantFailed:    []string{"alarm-0"},
+			wantSucceeded: []string{"alarm-1", "alarm-2", "alarm-3"},
+			wantCalls:     map[string]int{"alarm-0": 1, "alarm-1": 1, "alarm-2": 1, "alarm-3": 1},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakePutMetricAlarmAPI{throttles: tc.throttles, errs: tc.errs, calls: make(map[string]int)}
+			err := NewCreateCmd(alarmInputs(alarmNames(4)), api, testOptions).Execute(context.TODO())
+
+			assert.Equal(t, tc.wantCalls, api.calls)
+			assert.LessOrEqual(t, api.maxCalls, 2)
+
+			if len(tc.wantFailed) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var putErr *PutAlarmsError
+			require.ErrorAs(t, err, &putErr)
+			failed := make([]string, 0)
+			for name := range putErr.Failed {
+				failed = append(failed, name)
+			}
+			assert.ElementsMatch(t, tc.wantFailed, failed)
+			assert.Equal(t, tc.wantSucceeded, putErr.Succeeded)
+			for _, name := range tc.wantFailed {
+				assert.Contains(t, err.Error(), name)
+			}
+			for _, name := range tc.wantSucceeded {
+				assert.Contains(t, err.Error(), name)
+			}
+
+			var apiErr smithy.APIError
+			assert.ErrorAs(t, err, &apiErr)
+		})
+	}
+}
+
+func TestCreateCmd_Execute_tags(t *testing.T) {
+	t.Parallel()
+
+	api := &fakePutMetricAlarmAPI{
+		calls: make(map[string]int),
+		tags: map[string][]types.Tag{
+			alarmARN("existing"): {
+				tag("AWS_AUTO_ALARM_MANAGED", "true"),
+				tag("AWS_AUTO_ALARM_SOURCE_ARN", "arn:aws:sqs:us-east-1:123456789012:my-queue"),
+				tag("AWS_AUTO_ALARM_MUTED_UNTIL", "2026-11-01T06:00:00Z"),
+				tag("team", "payments"),
+				tag("env", "staging"),
+				tag("old", "value"),
+			},
+			alarmARN("unchanged"): {
+				tag("AWS_AUTO_ALARM_MANAGED", "true"),
+				tag("team", "payments"),
+			},
+		},
+	}
+	inputs := []*cloudwatch.PutMetricAlarmInput{
+		{
+			AlarmName: aws.String("existing"),
+			Tags:      []types.Tag{tag("AWS_AUTO_ALARM_MANAGED", "true"), tag("team", "payments"), tag("env", "prod")},
+		},
+		{
+			AlarmName: aws.String("unchanged"),
+			Tags:      []types.Tag{tag("AWS_AUTO_ALARM_MANAGED", "true"), tag("team", "payments")},
+		},
+		{
+			AlarmName: aws.String("new"),
+			Tags:      []types.Tag{tag("AWS_AUTO_ALARM_MANAGED", "true")},
+		},
+	}
+
+	err := NewCreateCmd(inputs, api, testOptions).Execute(context.TODO())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"existing": 1, "unchanged": 1, "new": 1}, api.calls)
+	assert.ElementsMatch(t, []string{"tag env=prod", "untag AWS_AUTO_ALARM_MUTED_UNTIL", "untag old"}, api.tagCalls)
+	assert.ElementsMatch(t, []types.Tag{
+		tag("AWS_AUTO_ALARM_MANAGED", "true"),
+		tag("AWS_AUTO_ALARM_SOURCE_ARN", "arn:aws:sqs:us-east-1:123456789012:my-queue"),
+		tag("team", "payments"),
+		tag("env", "prod"),
+	}, api.tags[alarmARN("existing")])
+}
+
+func TestCreateCmd_Execute_rateLimit(t *testing.T) {
+	t.Parallel()
+
+	api := &fakePutMetricAlarmAPI{calls: make(map[string]int)}
+	limiter := rate.NewLimiter(rate.Every(10*time.Millisecond), 1)
+
+	start := time.Now()
+	err := NewCreateCmd(alarmInputs(alarmNames(5)), api, testOptions, func(o *CreateOptions) {
+		o.Limiter = limiter
+	}).Execute(context.TODO())
+	require.NoError(t, err)
+
+	// the first call uses the burst, the remaining four wait for a token each
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestCreateCmd_Execute_rateLimitTags(t *testing.T) {
+	t.Parallel()
+
+	api := &fakePutMetricAlarmAPI{
+		calls: make(map[string]int),
+		tags: map[string][]types.Tag{
+			alarmARN("alarm-0"): {tag("team", "orders")},
+			alarmARN("alarm-1"): {tag("team", "orders")},
+		},
+	}
+	inputs := alarmInputs(alarmNames(2))
+	for _, in := range inputs {
+		in.Tags = []types.Tag{tag("team", "payments")}
+	}
+	limiter := rate.NewLimiter(rate.Every(10*time.Millisecond), 1)
+
+	start := time.Now()
+	err := NewCreateCmd(inputs, api, testOptions, func(o *CreateOptions) {
+		o.TagLimiter = limiter
+	}).Execute(context.TODO())
+	require.NoError(t, err)
+
+	// the tags of each alarm are listed and then tagged: the first call uses the burst and the other three wait, while
+	// the puts are not limited by the tag limiter
+	assert.Len(t, api.tagCalls, 2)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+type fakeDeleteAlarmsAPI struct {
+	inputs []*cloudwatch.DeleteAlarmsInput
+}
+
+func (f *fakeDeleteAlarmsAPI) DeleteAlarms(_ context.Context, in *cloudwatch.DeleteAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DeleteAlarmsOutput, error) {
+	f.inputs = append(f.inputs, in)
+	return &cloudwatch.DeleteAlarmsOutput{}, nil
+}
+
+func alarmNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("alarm-%d", i)
+	}
+	return names
+}
+
+func TestDeleteCmd_Execute(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		names       []string
+		wantBatches []int
+	}{
+		"no alarms makes no calls": {
+			names:       []string{},
+			wantBatches: []int{},
+		},
+		"single batch": {
+			names:       alarmNames(3),
+			wantBatches: []int{3},
+		},
+		"exact batch size": {
+			names:       alarmNames(100),
+			wantBatches: []int{100},
+		},
+		"multiple batches": {
+			names:       alarmNames(250),
+			wantBatches: []int{100, 100, 50},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := new(fakeDeleteAlarmsAPI)
+			err := NewDeleteCmd(&cloudwatch.DeleteAlarmsInput{AlarmNames: tc.names}, api).Execute(context.TODO())
+			require.NoError(t, err)
+
+			batches := make([]int, 0)
+			deleted := make([]string, 0)
+			for _, in := range api.inputs {
+				batches = append(batches, len(in.AlarmNames))
+				deleted = append(deleted, in.AlarmNames...)
+			}
+
+			assert.Equal(t, tc.wantBatches, batches)
+			assert.Equal(t, tc.names, deleted)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/time/rate"
+)
+
+// Environment variables that configure how alarms are put. See OptionsFromEnv.
+const (
+	ConcurrencyEnvVar          = "AWS_AUTO_ALARM_PUT_CONCURRENCY"
+	RequestsPerSecondEnvVar    = "AWS_AUTO_ALARM_PUT_RPS"
+	TagRequestsPerSecondEnvVar = "AWS_AUTO_ALARM_TAG_RPS"
+	MaxAttemptsEnvVar          = "AWS_AUTO_ALARM_PUT_MAX_ATTEMPTS"
+)
+
+// OptionsFromEnv returns the CreateOptions set by the ConcurrencyEnvVar, RequestsPerSecondEnvVar,
+// TagRequestsPerSecondEnvVar and MaxAttemptsEnvVar environment variables, read with lookup, such as os.LookupEnv.
+// Options that are not set keep their defaults. A rate sets a new limiter, which is shared by every CreateCmd made with
+// the returned options, so the options should be read once per process.
+func OptionsFromEnv(lookup func(string) (string, bool)) ([]func(*CreateOptions), error) {
+	optFns := make([]func(*CreateOptions), 0)
+
+	if v, ok := lookup(ConcurrencyEnvVar); ok && v != "" {
+		concurrency, err := strconv.Atoi(v)
+		if err != nil || concurrency < 1 {
+			return nil, fmt.Errorf("invalid %s %q: must be a whole number of at least 1", ConcurrencyEnvVar, v)
+		}
+		optFns = append(optFns, func(o *CreateOptions) { o.Concurrency = concurrency })
+	}
+
+	limiter, err := limiterFromEnv(lookup, RequestsPerSecondEnvVar)
+	if err != nil {
+		return nil, err
+	}
+	if limiter != nil {
+		optFns = append(optFns, func(o *CreateOptions) { o.Limiter = limiter })
+	}
+
+	tagLimiter, err := limiterFromEnv(lookup, TagRequestsPerSecondEnvVar)
+	if err != nil {
+		return nil, err
+	}
+	if tagLimiter != nil {
+		optFns = append(optFns, func(o *CreateOptions) { o.TagLimiter = tagLimiter })
+	}
+
+	if v, ok := lookup(MaxAttemptsEnvVar); ok && v != "" {
+		attempts, err := strconv.Atoi(v)
+		if err != nil || attempts < 1 {
+			return nil, fmt.Errorf("invalid %s %q: must be a whole number of at least 1", MaxAttemptsEnvVar, v)
+		}
+		optFns = append(optFns, func(o *CreateOptions) { o.MaxAttempts = attempts })
+	}
+
+	return optFns, nil
+}
+
+// limiterFromEnv returns a limiter with the requests per second of the environment variable and the default burst, or
+// nil when the variable is not set.
+func limiterFromEnv(lookup func(string) (string, bool), key string) (*rate.Limiter, error) {
+	v, ok := lookup(key)
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	rps, err := strconv.ParseFloat(v, 64)
+	if err != nil || rps <= 0 {
+		return nil, fmt.Errorf("invalid %s %q: must be a number greater than 0", key, v)
+	}
+
+	return rate.NewLimiter(rate.Limit(rps), defaultBurst), nil
+}
@@ -0,0 +1,84 @@
+package cloudwatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Parallel()
+
+	type options struct {
+		Concurrency int
+		MaxAttempts int
+		Limit       rate.Limit
+		TagLimit    rate.Limit
+	}
+
+	cases := map[string]struct {
+		env     map[string]string
+		want    options
+		wantErr string
+	}{
+		"defaults without variables": {
+			env:  map[string]string{},
+			want: options{Concurrency: defaultConcurrency, MaxAttempts: defaultMaxAttempts, Limit: defaultRequestsPerSecond, TagLimit: defaultTagRequestsPerSecond},
+		},
+		"variables set the options": {
+			env: map[string]string{
+				ConcurrencyEnvVar:          "8",
+				RequestsPerSecondEnvVar:    "0.5",
+				TagRequestsPerSecondEnvVar: "10",
+				MaxAttemptsEnvVar:          "10",
+			},
+			want: options{Concurrency: 8, MaxAttempts: 10, Limit: 0.5, TagLimit: 10},
+		},
+		"empty variables are ignored": {
+			env:  map[string]string{ConcurrencyEnvVar: ""},
+			want: options{Concurrency: defaultConcurrency, MaxAttempts: defaultMaxAttempts, Limit: defaultRequestsPerSecond, TagLimit: defaultTagRequestsPerSecond},
+		},
+		"invalid concurrency": {
+			env:     map[string]string{ConcurrencyEnvVar: "0"},
+			wantErr: `invalid AWS_AUTO_ALARM_PUT_CONCURRENCY "0"`,
+		},
+		"invalid rate": {
+			env:     map[string]string{RequestsPerSecondEnvVar: "fast"},
+			wantErr: `invalid AWS_AUTO_ALARM_PUT_RPS "fast"`,
+		},
+		"invalid tag rate": {
+			env:     map[string]string{TagRequestsPerSecondEnvVar: "0"},
+			wantErr: `invalid AWS_AUTO_ALARM_TAG_RPS "0"`,
+		},
+		"invalid attempts": {
+			env:     map[string]string{MaxAttemptsEnvVar: "-1"},
+			wantErr: `invalid AWS_AUTO_ALARM_PUT_MAX_ATTEMPTS "-1"`,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			optFns, err := OptionsFromEnv(func(key string) (string, bool) {
+				v, ok := tc.env[key]
+				return v, ok
+			})
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			cmd := NewCreateCmd(nil, nil, optFns...)
+			assert.Equal(t, tc.want.Concurrency, cmd.options.Concurrency)
+			assert.Equal(t, tc.want.MaxAttempts, cmd.options.MaxAttempts)
+			assert.Equal(t, tc.want.Limit, cmd.options.Limiter.Limit())
+			assert.Equal(t, tc.want.TagLimit, cmd.options.TagLimiter.Limit())
+			assert.Equal(t, defaultBurst, cmd.options.Limiter.Burst())
+		})
+	}
+}
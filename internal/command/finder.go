@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+	"github.com/akijowski/aws-auto-alarm/internal/template"
+)
+
+// NewAlarmNameFinder returns the AlarmNameFinder for the delete strategy in the config.Config.
+// Alarms are found by their ownership tags unless the template strategy is selected, which renders the templates
+// from the templates fs.FS with data from the template.ResourceMapper.
+func NewAlarmNameFinder(ctx context.Context, cfg *config.Config, api autoalarm.GetResourcesAPI, m template.ResourceMapper, templates fs.FS) (AlarmNameFinder, error) {
+	switch cfg.DeleteStrategy {
+	case "", config.DeleteStrategyTags:
+		if api == nil {
+			return nil, errors.New("a resources API is required to find alarms by tags")
+		}
+		return autoalarm.NewNameFinder(api, cfg.ParsedARN), nil
+	case config.DeleteStrategyTemplate:
+		return template.NewFileFinder(ctx, cfg, m, templates), nil
+	default:
+		return nil, fmt.Errorf("unsupported delete strategy: %s", cfg.DeleteStrategy)
+	}
+}
+
+// NewPruneFinder returns the AlarmNameFinder used to find managed alarms to prune after an upsert.
+// It returns nil when pruning is disabled in the config.Config or there is no resources API to search with.
+func NewPruneFinder(cfg *config.Config, api autoalarm.GetResourcesAPI) AlarmNameFinder {
+	if cfg.DisablePrune || api == nil {
+		return nil
+	}
+
+	return autoalarm.NewNameFinder(api, cfg.ParsedARN)
+}
@@ -0,0 +1,131 @@
+// Package plan compares rendered alarms with the alarms that exist in CloudWatch and writes the changes that would
+// be made, without making them.
+package plan
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+)
+
+type CreateCmd struct {
+	inputs []*cloudwatch.PutMetricAlarmInput
+	stale  []string
+	api    autoalarm.PlanAlarmAPI
+	wr     io.Writer
+}
+
+// NewCreateCmd returns a CreateCmd that plans the upsert of inputs, followed by the deletion of the stale alarm names.
+// The tags of the existing alarms are compared as well, since they are converged when the alarms are put.
+func NewCreateCmd(inputs []*cloudwatch.PutMetricAlarmInput, stale []string, api autoalarm.PlanAlarmAPI, wr io.Writer) *CreateCmd {
+	return &CreateCmd{
+		inputs: inputs,
+		stale:  stale,
+		api:    api,
+		wr:     wr,
+	}
+}
+
+type DeleteCmd struct {
+	input *cloudwatch.DeleteAlarmsInput
+	api   autoalarm.DescribeAlarmsAPI
+	wr    io.Writer
+}
+
+func NewDeleteCmd(input *cloudwatch.DeleteAlarmsInput, api autoalarm.DescribeAlarmsAPI, wr io.Writer) *DeleteCmd {
+	return &DeleteCmd{
+		input: input,
+		api:   api,
+		wr:    wr,
+	}
+}
+
+func (c *CreateCmd) Execute(ctx context.Context) error {
+	logger := log.Ctx(ctx)
+	logger.Debug().Msg("planning changes against Cloudwatch")
+
+	names := make([]string, 0, len(c.inputs)+len(c.stale))
+	for _, in := range c.inputs {
+		names = append(names, aws.ToString(in.AlarmName))
+	}
+	names = append(names, c.stale...)
+
+	existing, err := autoalarm.DescribeMetricAlarms(ctx, c.api, names)
+	if err != nil {
+		return err
+	}
+
+	tags, err := c.listTags(ctx, existing)
+	if err != nil {
+		return err
+	}
+
+	changes := append(planCreate(c.inputs, existing, tags), planDelete(c.stale, existing)...)
+
+	return writePlan(c.wr, changes)
+}
+
+// listTags returns the tags of the existing alarms that are rendered, keyed by alarm name.
+func (c *CreateCmd) listTags(ctx context.Context, existing map[string]types.MetricAlarm) (map[string][]types.Tag, error) {
+	tags := make(map[string][]types.Tag)
+	for _, in := range c.inputs {
+		alarm, ok := existing[aws.ToString(in.AlarmName)]
+		if !ok {
+			continue
+		}
+
+		out, err := c.api.ListTagsForResource(ctx, &cloudwatch.ListTagsForResourceInput{ResourceARN: alarm.AlarmArn})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list tags of alarm %s: %w", aws.ToString(alarm.AlarmName), err)
+		}
+		tags[aws.ToString(alarm.AlarmName)] = out.Tags
+	}
+
+	return tags, nil
+}
+
+func (d *DeleteCmd) Execute(ctx context.Context) error {
+	logger := log.Ctx(ctx)
+	logger.Debug().Msg("planning changes against Cloudwatch")
+
+	existing, err := autoalarm.DescribeMetricAlarms(ctx, d.api, d.input.AlarmNames)
+	if err != nil {
+		return err
+	}
+
+	return writePlan(d.wr, planDelete(d.input.AlarmNames, existing))
+}
+
+var actionSymbols = map[action]string{
+	actionCreate: "+",
+	actionUpdate: "~",
+	actionNoOp:   "=",
+	actionDelete: "-",
+}
+
+func writePlan(wr io.Writer, changes []change) error {
+	counts := make(map[action]int)
+	for _, c := range changes {
+		counts[c.action]++
+		if _, err := fmt.Fprintf(wr, "%s %s: %s\n", actionSymbols[c.action], c.action, c.alarmName); err != nil {
+			return err
+		}
+		for _, f := range c.fields {
+			if _, err := fmt.Fprintf(wr, "    %s: %s -> %s\n", f.name, f.from, f.to); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(wr, "\nPlan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		counts[actionCreate], counts[actionUpdate], counts[actionDelete], counts[actionNoOp])
+
+	return err
+}
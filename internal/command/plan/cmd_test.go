@@ -0,0 +1,210 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDescribeAlarmsAPI struct {
+	alarms []types.MetricAlarm
+	inputs []*cloudwatch.DescribeAlarmsInput
+	// tags are the tags of each alarm, by alarm ARN
+	tags map[string][]types.Tag
+}
+
+func (f *fakeDescribeAlarmsAPI) ListTagsForResource(_ context.Context, in *cloudwatch.ListTagsForResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.ListTagsForResourceOutput, error) {
+	return &cloudwatch.ListTagsForResourceOutput{Tags: f.tags[aws.ToString(in.ResourceARN)]}, nil
+}
+
+func (f *fakeDescribeAlarmsAPI) DescribeAlarms(_ context.Context, in *cloudwatch.DescribeAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	f.inputs = append(f.inputs, in)
+	out := new(cloudwatch.DescribeAlarmsOutput)
+	for _, alarm := range f.alarms {
+		for _, name := range in.AlarmNames {
+			if aws.ToString(alarm.AlarmName) == name {
+				out.MetricAlarms = append(out.MetricAlarms, alarm)
+			}
+		}
+	}
+	return out, nil
+}
+
+func queueAlarm(name string, threshold float64, actions ...string) *cloudwatch.PutMetricAlarmInput {
+	return &cloudwatch.PutMetricAlarmInput{
+		AlarmName:          aws.String(name),
+		ActionsEnabled:     aws.Bool(true),
+		AlarmActions:       actions,
+		ComparisonOperator: types.ComparisonOperatorGreaterThanThreshold,
+		EvaluationPeriods:  aws.Int32(15),
+		MetricName:         aws.String("ApproximateNumberOfMessagesVisible"),
+		Namespace:          aws.String("AWS/SQS"),
+		Period:             aws.Int32(60),
+		Statistic:          types.StatisticSum,
+		Threshold:          aws.Float64(threshold),
+		Dimensions: []types.Dimension{
+			{Name: aws.String("QueueName"), Value: aws.String("test-queue")},
+		},
+	}
+}
+
+func existingAlarm(in *cloudwatch.PutMetricAlarmInput) types.MetricAlarm {
+	return types.MetricAlarm{
+		AlarmArn:           aws.String(alarmARN(aws.ToString(in.AlarmName))),
+		AlarmName:          in.AlarmName,
+		ActionsEnabled:     in.ActionsEnabled,
+		AlarmActions:       in.AlarmActions,
+		ComparisonOperator: in.ComparisonOperator,
+		EvaluationPeriods:  in.EvaluationPeriods,
+		MetricName:         in.MetricName,
+		Namespace:          in.Namespace,
+		Period:             in.Period,
+		Statistic:          in.Statistic,
+		Threshold:          in.Threshold,
+		Dimensions:         in.Dimensions,
+		TreatMissingData:   aws.String("missing"),
+	}
+}
+
+func alarmARN(name string) string {
+	return "arn:aws:cloudwatch:us-east-1:123456789012:alarm:" + name
+}
+
+func tag(key, value string) types.Tag {
+	return types.Tag{Key: aws.String(key), Value: aws.String(value)}
+}
+
+func TestCreateCmd_Execute(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeDescribeAlarmsAPI{
+		alarms: []types.MetricAlarm{
+			existingAlarm(queueAlarm("unchanged", 0, "sns1")),
+			existingAlarm(queueAlarm("changed", 100, "sns1")),
+			existingAlarm(queueAlarm("stale", 0)),
+		},
+		tags: map[string][]types.Tag{
+			alarmARN("unchanged"): {tag("team", "payments"), tag("env", "prod")},
+			alarmARN("changed"):   {tag("team", "orders")},
+		},
+	}
+
+	unchanged := queueAlarm("unchanged", 0, "sns1")
+	unchanged.Tags = []types.Tag{tag("env", "prod"), tag("team", "payments")}
+	changed := queueAlarm("changed", 250, "sns2")
+	changed.Tags = []types.Tag{tag("team", "payments")}
+	inputs := []*cloudwatch.PutMetricAlarmInput{unchanged, changed, queueAlarm("new", 0)}
+
+	buf := new(bytes.Buffer)
+	err := NewCreateCmd(inputs, []string{"stale"}, api, buf).Execute(context.TODO())
+	require.NoError(t, err)
+
+	assert.Equal(t, `= no-op: unchanged
+~ update: changed
+    AlarmActions: [sns1] -> [sns2]
+    Tags: [team=orders] -> [team=payments]
+    Threshold: 100 -> 250
++ create: new
+- delete: stale
+
+Plan: 1 to create, 1 to update, 1 to delete, 1 unchanged.
+`, buf.String())
+}
+
+func TestDeleteCmd_Execute(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeDescribeAlarmsAPI{
+		alarms: []types.MetricAlarm{
+			existingAlarm(queueAlarm("exists", 0)),
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	err := NewDeleteCmd(&cloudwatch.DeleteAlarmsInput{AlarmNames: []string{"exists", "missing"}}, api, buf).
+		Execute(context.TODO())
+	require.NoError(t, err)
+
+	assert.Equal(t, `- delete: exists
+
+Plan: 0 to create, 0 to update, 1 to delete, 0 unchanged.
+`, buf.String())
+}
+
+func Test_diff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unset fields match CloudWatch defaults", func(t *testing.T) {
+		t.Parallel()
+
+		rendered := queueAlarm("alarm", 0)
+		rendered.ActionsEnabled = nil
+		existing := alarmInput(existingAlarm(queueAlarm("alarm", 0)), nil)
+
+		assert.Empty(t, diff(existing, rendered))
+	})
+
+	t.Run("order of actions and dimensions is ignored", func(t *testing.T) {
+		t.Parallel()
+
+		rendered := queueAlarm("alarm", 0, "sns1", "sns2")
+		rendered.Dimensions = append(rendered.Dimensions, types.Dimension{Name: aws.String("A"), Value: aws.String("B")})
+		existing := queueAlarm("alarm", 0, "sns2", "sns1")
+		existing.Dimensions = []types.Dimension{rendered.Dimensions[1], rendered.Dimensions[0]}
+
+		assert.Empty(t, diff(existing, rendered))
+	})
+	t.Run("metric queries are compared by their set fields", func(t *testing.T) {
+		t.Parallel()
+
+		query := func(id string) types.MetricDataQuery {
+			return types.MetricDataQuery{
+				Id: aws.String(id),
+				MetricStat: &types.MetricStat{
+					Metric: &types.Metric{
+						MetricName: aws.String("Errors"),
+						Namespace:  aws.String("AWS/Lambda"),
+					},
+					Period: aws.Int32(60),
+					Stat:   aws.String("Sum"),
+				},
+				ReturnData: aws.Bool(false),
+			}
+		}
+
+		rendered := queueAlarm("alarm", 0)
+		rendered.Metrics = []types.MetricDataQuery{
+			{Id: aws.String("rate"), Expression: aws.String("errors / 60")},
+			query("errors"),
+		}
+		existing := queueAlarm("alarm", 0)
+		existing.Metrics = []types.MetricDataQuery{
+			query("errors"),
+			{Id: aws.String("rate"), Expression: aws.String("errors / 60"), Label: aws.String(""), ReturnData: aws.Bool(true)},
+		}
+		existing.Metrics[0].MetricStat.Metric.Dimensions = []types.Dimension{}
+
+		assert.Empty(t, diff(existing, rendered))
+
+		rendered.Metrics[0].Expression = aws.String("errors / 300")
+		changes := diff(existing, rendered)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "Metrics", changes[0].name)
+	})
+
+	t.Run("tags are compared in any order", func(t *testing.T) {
+		t.Parallel()
+
+		rendered := queueAlarm("alarm", 0)
+		rendered.Tags = []types.Tag{tag("team", "payments"), tag("env", "prod")}
+		existing := alarmInput(existingAlarm(queueAlarm("alarm", 0)), []types.Tag{tag("env", "prod"), tag("team", "payments")})
+
+		assert.Empty(t, diff(existing, rendered))
+	})
+}
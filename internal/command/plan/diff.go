@@ -0,0 +1,225 @@
+package plan
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+type action string
+
+const (
+	actionCreate action = "create"
+	actionUpdate action = "update"
+	actionNoOp   action = "no-op"
+	actionDelete action = "delete"
+)
+
+// change is the planned change for a single alarm.
+type change struct {
+	action    action
+	alarmName string
+	fields    []fieldChange
+}
+
+// fieldChange is a difference in a single field between the existing and rendered alarm.
+type fieldChange struct {
+	name     string
+	from, to string
+}
+
+// alarmField formats a single field of an alarm for comparison and display.
+type alarmField struct {
+	name   string
+	format func(in *cloudwatch.PutMetricAlarmInput) string
+}
+
+// alarmFields are compared, in order, when planning an update.
+// Values are normalized so that an unset field matches the default CloudWatch reports for it.
+var alarmFields = []alarmField{
+	{"ActionsEnabled", func(in *cloudwatch.PutMetricAlarmInput) string {
+		return strconv.FormatBool(in.ActionsEnabled == nil || *in.ActionsEnabled)
+	}},
+	{"AlarmActions", func(in *cloudwatch.PutMetricAlarmInput) string { return formatSet(in.AlarmActions) }},
+	{"AlarmDescription", func(in *cloudwatch.PutMetricAlarmInput) string { return aws.ToString(in.AlarmDescription) }},
+	{"ComparisonOperator", func(in *cloudwatch.PutMetricAlarmInput) string { return string(in.ComparisonOperator) }},
+	{"DatapointsToAlarm", func(in *cloudwatch.PutMetricAlarmInput) string { return formatInt(in.DatapointsToAlarm) }},
+	{"Dimensions", func(in *cloudwatch.PutMetricAlarmInput) string { return formatDimensions(in.Dimensions) }},
+	{"EvaluateLowSampleCountPercentile", func(in *cloudwatch.PutMetricAlarmInput) string {
+		return aws.ToString(in.EvaluateLowSampleCountPercentile)
+	}},
+	{"EvaluationPeriods", func(in *cloudwatch.PutMetricAlarmInput) string { return formatInt(in.EvaluationPeriods) }},
+	{"ExtendedStatistic", func(in *cloudwatch.PutMetricAlarmInput) string { return aws.ToString(in.ExtendedStatistic) }},
+	{"InsufficientDataActions", func(in *cloudwatch.PutMetricAlarmInput) string {
+		return formatSet(in.InsufficientDataActions)
+	}},
+	{"MetricName", func(in *cloudwatch.PutMetricAlarmInput) string { return aws.ToString(in.MetricName) }},
+	{"Metrics", func(in *cloudwatch.PutMetricAlarmInput) string { return formatMetrics(in.Metrics) }},
+	{"Namespace", func(in *cloudwatch.PutMetricAlarmInput) string { return aws.ToString(in.Namespace) }},
+	{"OKActions", func(in *cloudwatch.PutMetricAlarmInput) string { return formatSet(in.OKActions) }},
+	{"Period", func(in *cloudwatch.PutMetricAlarmInput) string { return formatInt(in.Period) }},
+	{"Statistic", func(in *cloudwatch.PutMetricAlarmInput) string { return string(in.Statistic) }},
+	{"Tags", func(in *cloudwatch.PutMetricAlarmInput) string { return formatTags(in.Tags) }},
+	{"Threshold", func(in *cloudwatch.PutMetricAlarmInput) string {
+		if in.Threshold == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*in.Threshold, 'f', -1, 64)
+	}},
+	{"ThresholdMetricId", func(in *cloudwatch.PutMetricAlarmInput) string { return aws.ToString(in.ThresholdMetricId) }},
+	{"TreatMissingData", func(in *cloudwatch.PutMetricAlarmInput) string {
+		if in.TreatMissingData == nil || *in.TreatMissingData == "" {
+			return "missing"
+		}
+		return *in.TreatMissingData
+	}},
+	{"Unit", func(in *cloudwatch.PutMetricAlarmInput) string { return string(in.Unit) }},
+}
+
+// diff returns the changes needed to turn the existing alarm into the rendered alarm.
+func diff(existing, rendered *cloudwatch.PutMetricAlarmInput) []fieldChange {
+	changes := make([]fieldChange, 0)
+	for _, field := range alarmFields {
+		from, to := field.format(existing), field.format(rendered)
+		if from != to {
+			changes = append(changes, fieldChange{name: field.name, from: from, to: to})
+		}
+	}
+
+	return changes
+}
+
+// planCreate compares the rendered alarms with the existing alarms and their tags, keyed by name.
+func planCreate(rendered []*cloudwatch.PutMetricAlarmInput, existing map[string]types.MetricAlarm, tags map[string][]types.Tag) []change {
+	changes := make([]change, 0, len(rendered))
+	for _, in := range rendered {
+		name := aws.ToString(in.AlarmName)
+		alarm, ok := existing[name]
+		if !ok {
+			changes = append(changes, change{action: actionCreate, alarmName: name})
+			continue
+		}
+
+		fields := diff(alarmInput(alarm, tags[name]), in)
+		if len(fields) == 0 {
+			changes = append(changes, change{action: actionNoOp, alarmName: name})
+			continue
+		}
+		changes = append(changes, change{action: actionUpdate, alarmName: name, fields: fields})
+	}
+
+	return changes
+}
+
+// planDelete returns a delete change for each of the names that exist.
+func planDelete(names []string, existing map[string]types.MetricAlarm) []change {
+	changes := make([]change, 0, len(names))
+	for _, name := range names {
+		if _, ok := existing[name]; ok {
+			changes = append(changes, change{action: actionDelete, alarmName: name})
+		}
+	}
+
+	return changes
+}
+
+// alarmInput converts an existing alarm and its tags into the input that would have created it.
+func alarmInput(alarm types.MetricAlarm, tags []types.Tag) *cloudwatch.PutMetricAlarmInput {
+	return &cloudwatch.PutMetricAlarmInput{
+		AlarmName:                        alarm.AlarmName,
+		ActionsEnabled:                   alarm.ActionsEnabled,
+		AlarmActions:                     alarm.AlarmActions,
+		AlarmDescription:                 alarm.AlarmDescription,
+		ComparisonOperator:               alarm.ComparisonOperator,
+		DatapointsToAlarm:                alarm.DatapointsToAlarm,
+		Dimensions:                       alarm.Dimensions,
+		EvaluateLowSampleCountPercentile: alarm.EvaluateLowSampleCountPercentile,
+		EvaluationPeriods:                alarm.EvaluationPeriods,
+		ExtendedStatistic:                alarm.ExtendedStatistic,
+		InsufficientDataActions:          alarm.InsufficientDataActions,
+		MetricName:                       alarm.MetricName,
+		Metrics:                          alarm.Metrics,
+		Namespace:                        alarm.Namespace,
+		OKActions:                        alarm.OKActions,
+		Period:                           alarm.Period,
+		Statistic:                        alarm.Statistic,
+		Tags:                             tags,
+		Threshold:                        alarm.Threshold,
+		ThresholdMetricId:                alarm.ThresholdMetricId,
+		TreatMissingData:                 alarm.TreatMissingData,
+		Unit:                             alarm.Unit,
+	}
+}
+
+func formatInt(i *int32) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*i))
+}
+
+// formatSet formats values whose order is not meaningful, such as alarm actions.
+func formatSet(values []string) string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return "[" + strings.Join(sorted, ", ") + "]"
+}
+
+func formatDimensions(dims []types.Dimension) string {
+	values := make([]string, 0, len(dims))
+	for _, d := range dims {
+		values = append(values, fmt.Sprintf("%s=%s", aws.ToString(d.Name), aws.ToString(d.Value)))
+	}
+	return formatSet(values)
+}
+
+func formatTags(tags []types.Tag) string {
+	values := make([]string, 0, len(tags))
+	for _, t := range tags {
+		values = append(values, fmt.Sprintf("%s=%s", aws.ToString(t.Key), aws.ToString(t.Value)))
+	}
+	return formatSet(values)
+}
+
+// formatMetrics formats the metric math queries field by field, so that fields CloudWatch reports as empty or with
+// their defaults, such as ReturnData, match the unset fields of a rendered query. The order of the queries and their
+// dimensions is ignored.
+func formatMetrics(metrics []types.MetricDataQuery) string {
+	queries := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		fields := []string{"Id=" + aws.ToString(m.Id)}
+		fields = appendField(fields, "AccountId", aws.ToString(m.AccountId))
+		fields = appendField(fields, "Expression", aws.ToString(m.Expression))
+		fields = appendField(fields, "Label", aws.ToString(m.Label))
+		fields = appendField(fields, "Period", formatInt(m.Period))
+		if stat := m.MetricStat; stat != nil {
+			if metric := stat.Metric; metric != nil {
+				fields = appendField(fields, "Namespace", aws.ToString(metric.Namespace))
+				fields = appendField(fields, "MetricName", aws.ToString(metric.MetricName))
+				if len(metric.Dimensions) > 0 {
+					fields = appendField(fields, "Dimensions", formatDimensions(metric.Dimensions))
+				}
+			}
+			fields = appendField(fields, "StatPeriod", formatInt(stat.Period))
+			fields = appendField(fields, "Stat", aws.ToString(stat.Stat))
+			fields = appendField(fields, "Unit", string(stat.Unit))
+		}
+		fields = append(fields, "ReturnData="+strconv.FormatBool(m.ReturnData == nil || *m.ReturnData))
+		queries = append(queries, "{"+strings.Join(fields, " ")+"}")
+	}
+
+	return formatSet(queries)
+}
+
+// appendField appends name=value to fields, unless the value is empty.
+func appendField(fields []string, name, value string) []string {
+	if value == "" {
+		return fields
+	}
+	return append(fields, name+"="+value)
+}
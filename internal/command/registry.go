@@ -5,13 +5,35 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/rs/zerolog/log"
 
 	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/command/cloudformation"
 	cmdcw "github.com/akijowski/aws-auto-alarm/internal/command/cloudwatch"
 	"github.com/akijowski/aws-auto-alarm/internal/command/json"
+	"github.com/akijowski/aws-auto-alarm/internal/command/plan"
+	"github.com/akijowski/aws-auto-alarm/internal/command/terraform"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
 )
 
+// CommandType returns the type of command to run for the config.Config.
+// Plans take precedence over dry runs, which write the alarms in the Output format, JSON by default, instead of sending
+// them to Cloudwatch.
+func CommandType(cfg *config.Config) string {
+	switch {
+	case cfg.Plan:
+		return "plan"
+	case cfg.DryRun && cfg.Output != "":
+		return cfg.Output
+	case cfg.DryRun:
+		return config.OutputJSON
+	default:
+		return "cloudwatch"
+	}
+}
+
 type AlarmLoader interface {
 	Load(ctx context.Context) ([]*cloudwatch.PutMetricAlarmInput, error)
 }
@@ -22,21 +44,26 @@ type AlarmNameFinder interface {
 
 // Registry is used to generate create and delete commands.
 type Registry struct {
-	api autoalarm.MetricAlarmAPI
-	wr  io.Writer
+	api        autoalarm.MetricAlarmAPI
+	wr         io.Writer
+	createOpts []func(*cmdcw.CreateOptions)
 }
 
 // DefaultRegistry returns a new Registry using the provided api and writer.
-func DefaultRegistry(api autoalarm.MetricAlarmAPI, wr io.Writer) *Registry {
+// The optFns configure the cloudwatch create command, such as its concurrency and rate limit.
+func DefaultRegistry(api autoalarm.MetricAlarmAPI, wr io.Writer, optFns ...func(*cmdcw.CreateOptions)) *Registry {
 	// use a map instead? map[string]Command embedded in the Registry
 	return &Registry{
 		api,
 		wr,
+		optFns,
 	}
 }
 
 // CreateCommand returns an autoalarm.Command for creation or upsert based on the type t and the input from AlarmLoader.
-func (r *Registry) CreateCommand(ctx context.Context, t string, l AlarmLoader) (autoalarm.Command, error) {
+// When the AlarmNameFinder p is not nil, managed alarms it finds that are not part of the input are pruned after the
+// upsert. Pruning is not applied to the json, cloudformation and terraform types.
+func (r *Registry) CreateCommand(ctx context.Context, t string, l AlarmLoader, p AlarmNameFinder) (autoalarm.Command, error) {
 	in, err := l.Load(ctx)
 	if err != nil {
 		return nil, err
@@ -45,8 +72,25 @@ func (r *Registry) CreateCommand(ctx context.Context, t string, l AlarmLoader) (
 	switch t {
 	case "json":
 		return json.NewCreateCmd(in, r.wr), nil
+	case "cloudformation":
+		return cloudformation.NewCreateCmd(in, r.wr), nil
+	case "terraform":
+		return terraform.NewCreateCmd(in, r.wr), nil
 	case "cloudwatch":
-		return cmdcw.NewCreateCmd(in, r.api), nil
+		stale, err := staleAlarms(ctx, p, in)
+		if err != nil {
+			return nil, err
+		}
+		return sequence{
+			cmdcw.NewCreateCmd(in, r.api, r.createOpts...),
+			cmdcw.NewDeleteCmd(&cloudwatch.DeleteAlarmsInput{AlarmNames: stale}, r.api),
+		}, nil
+	case "plan":
+		stale, err := staleAlarms(ctx, p, in)
+		if err != nil {
+			return nil, err
+		}
+		return plan.NewCreateCmd(in, stale, r.api, r.wr), nil
 	default:
 		return nil, fmt.Errorf("unsupported command type: %s", t)
 	}
@@ -66,7 +110,51 @@ func (r *Registry) DeleteCommand(ctx context.Context, t string, f AlarmNameFinde
 		return json.NewDeleteCmd(in, r.wr), nil
 	case "cloudwatch":
 		return cmdcw.NewDeleteCmd(in, r.api), nil
+	case "plan":
+		return plan.NewDeleteCmd(in, r.api, r.wr), nil
+	case "cloudformation", "terraform":
+		return nil, fmt.Errorf("the %s command type cannot delete alarms: remove their resources instead", t)
 	default:
 		return nil, fmt.Errorf("unsupported command type: %s", t)
 	}
 }
+
+// staleAlarms returns the names found by the AlarmNameFinder that are not in the rendered alarms.
+func staleAlarms(ctx context.Context, f AlarmNameFinder, rendered []*cloudwatch.PutMetricAlarmInput) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	names, err := f.Find(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find alarms to prune: %w", err)
+	}
+
+	keep := make(map[string]struct{}, len(rendered))
+	for _, in := range rendered {
+		keep[aws.ToString(in.AlarmName)] = struct{}{}
+	}
+
+	stale := make([]string, 0)
+	for _, name := range names {
+		if _, ok := keep[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+
+	log.Ctx(ctx).Debug().Strs("stale_alarms", stale).Msg("found alarms to prune")
+
+	return stale, nil
+}
+
+// sequence is an autoalarm.Command that executes commands in order, stopping at the first error.
+type sequence []autoalarm.Command
+
+func (s sequence) Execute(ctx context.Context) error {
+	for _, cmd := range s {
+		if err := cmd.Execute(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	cmdcw "github.com/akijowski/aws-auto-alarm/internal/command/cloudwatch"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+type fakeLoader []*cloudwatch.PutMetricAlarmInput
+
+func (l fakeLoader) Load(_ context.Context) ([]*cloudwatch.PutMetricAlarmInput, error) {
+	return l, nil
+}
+
+type fakeFinder []string
+
+func (f fakeFinder) Find(_ context.Context) ([]string, error) {
+	return f, nil
+}
+
+type fakeMetricAlarmAPI struct {
+	calls []string
+}
+
+func (f *fakeMetricAlarmAPI) PutMetricAlarm(_ context.Context, in *cloudwatch.PutMetricAlarmInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	f.calls = append(f.calls, "put "+aws.ToString(in.AlarmName))
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) DeleteAlarms(_ context.Context, in *cloudwatch.DeleteAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DeleteAlarmsOutput, error) {
+	for _, name := range in.AlarmNames {
+		f.calls = append(f.calls, "delete "+name)
+	}
+	return &cloudwatch.DeleteAlarmsOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) DescribeAlarms(_ context.Context, _ *cloudwatch.DescribeAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	return &cloudwatch.DescribeAlarmsOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) ListTagsForResource(_ context.Context, _ *cloudwatch.ListTagsForResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.ListTagsForResourceOutput, error) {
+	return &cloudwatch.ListTagsForResourceOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) TagResource(_ context.Context, _ *cloudwatch.TagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.TagResourceOutput, error) {
+	return &cloudwatch.TagResourceOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) UntagResource(_ context.Context, _ *cloudwatch.UntagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.UntagResourceOutput, error) {
+	return &cloudwatch.UntagResourceOutput{}, nil
+}
+
+func TestRegistry_CreateCommand(t *testing.T) {
+	t.Parallel()
+
+	loader := fakeLoader{
+		{AlarmName: aws.String("current-1")},
+		{AlarmName: aws.String("current-2")},
+	}
+
+	cases := map[string]struct {
+		pruner    AlarmNameFinder
+		wantCalls []string
+	}{
+		"prunes stale alarms after upsert": {
+			pruner:    fakeFinder{"current-1", "stale", "current-2"},
+			wantCalls: []string{"put current-1", "put current-2", "delete stale"},
+		},
+		"does not prune without a finder": {
+			wantCalls: []string{"put current-1", "put current-2"},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := new(fakeMetricAlarmAPI)
+			// a single worker keeps the put calls in order
+			registry := DefaultRegistry(api, new(bytes.Buffer), func(o *cmdcw.CreateOptions) {
+				o.Concurrency = 1
+				o.Limiter = rate.NewLimiter(rate.Inf, 1)
+				o.TagLimiter = rate.NewLimiter(rate.Inf, 1)
+			})
+			cmd, err := registry.CreateCommand(context.TODO(), "cloudwatch", loader, tc.pruner)
+			require.NoError(t, err)
+
+			require.NoError(t, cmd.Execute(context.TODO()))
+			assert.Equal(t, tc.wantCalls, api.calls)
+		})
+	}
+}
+
+func TestCommandType(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		cfg  *config.Config
+		want string
+	}{
+		"cloudwatch by default": {
+			cfg:  &config.Config{},
+			want: "cloudwatch",
+		},
+		"dry runs write JSON": {
+			cfg:  &config.Config{DryRun: true},
+			want: "json",
+		},
+		"dry runs write the output format": {
+			cfg:  &config.Config{DryRun: true, Output: config.OutputTerraform},
+			want: "terraform",
+		},
+		"the output format is ignored without a dry run": {
+			cfg:  &config.Config{Output: config.OutputCloudFormation},
+			want: "cloudwatch",
+		},
+		"plans take precedence": {
+			cfg:  &config.Config{DryRun: true, Plan: true, Output: config.OutputTerraform},
+			want: "plan",
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, CommandType(tc.cfg))
+		})
+	}
+}
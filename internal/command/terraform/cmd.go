@@ -0,0 +1,284 @@
+// Package terraform writes rendered alarms as Terraform aws_cloudwatch_metric_alarm resources.
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	alarmResourceType = "aws_cloudwatch_metric_alarm"
+	hashLength        = 8
+)
+
+type CreateCmd struct {
+	inputs []*cloudwatch.PutMetricAlarmInput
+	wr     io.Writer
+}
+
+func NewCreateCmd(inputs []*cloudwatch.PutMetricAlarmInput, wr io.Writer) *CreateCmd {
+	return &CreateCmd{
+		inputs: inputs,
+		wr:     wr,
+	}
+}
+
+// Execute writes an aws_cloudwatch_metric_alarm resource for each alarm, ordered by alarm name. See ResourceName.
+func (c *CreateCmd) Execute(ctx context.Context) error {
+	logger := log.Ctx(ctx)
+	logger.Debug().Msg("writing output as Terraform")
+
+	inputs := slices.Clone(c.inputs)
+	slices.SortFunc(inputs, func(a, b *cloudwatch.PutMetricAlarmInput) int {
+		return strings.Compare(aws.ToString(a.AlarmName), aws.ToString(b.AlarmName))
+	})
+
+	buf := new(bytes.Buffer)
+	for i, in := range inputs {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		name := aws.ToString(in.AlarmName)
+		fmt.Fprintf(buf, "resource %s %s {\n", quote(alarmResourceType), quote(ResourceName(name)))
+		alarmBody(in).write(buf, 1)
+		buf.WriteString("}\n")
+	}
+
+	_, err := c.wr.Write(buf.Bytes())
+	return err
+}
+
+// ResourceName returns the Terraform resource name for an alarm name. It is the letters and digits of the name in
+// lower case, with other characters replaced by underscores, followed by a short hash of the full name, so the same
+// alarm always has the same address and names that only differ by other characters, such as > and <, do not collide.
+func ResourceName(alarmName string) string {
+	sum := sha256.Sum256([]byte(alarmName))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(alarmName) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteRune('_')
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "_")
+	switch {
+	case name == "":
+		name = "alarm"
+	case unicode.IsDigit(rune(name[0])):
+		name = "alarm_" + name
+	}
+
+	return name + "_" + hash
+}
+
+// alarmBody returns the arguments and blocks of the aws_cloudwatch_metric_alarm resource for the alarm.
+func alarmBody(in *cloudwatch.PutMetricAlarmInput) *body {
+	b := new(body)
+	b.attr("alarm_name", quote(aws.ToString(in.AlarmName)))
+	b.optional("alarm_description", in.AlarmDescription)
+	b.attr("comparison_operator", quote(string(in.ComparisonOperator)))
+	b.int("evaluation_periods", in.EvaluationPeriods)
+	b.int("datapoints_to_alarm", in.DatapointsToAlarm)
+	b.optional("metric_name", in.MetricName)
+	b.optional("namespace", in.Namespace)
+	b.int("period", in.Period)
+	b.attrIf(in.Statistic != "", "statistic", quote(string(in.Statistic)))
+	b.optional("extended_statistic", in.ExtendedStatistic)
+	if in.Threshold != nil {
+		b.attr("threshold", formatFloat(aws.ToFloat64(in.Threshold)))
+	}
+	b.optional("threshold_metric_id", in.ThresholdMetricId)
+	b.attrIf(in.Unit != "", "unit", quote(string(in.Unit)))
+	b.optional("treat_missing_data", in.TreatMissingData)
+	b.optional("evaluate_low_sample_count_percentiles", in.EvaluateLowSampleCountPercentile)
+	if in.ActionsEnabled != nil {
+		b.attr("actions_enabled", strconv.FormatBool(aws.ToBool(in.ActionsEnabled)))
+	}
+	b.list("alarm_actions", in.AlarmActions)
+	b.list("ok_actions", in.OKActions)
+	b.list("insufficient_data_actions", in.InsufficientDataActions)
+	b.object("dimensions", dimensions(in.Dimensions))
+
+	for _, query := range in.Metrics {
+		b.block("metric_query", queryBody(query))
+	}
+
+	tags := make(map[string]string, len(in.Tags))
+	for _, tag := range in.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	b.object("tags", tags)
+
+	return b
+}
+
+func queryBody(query types.MetricDataQuery) *body {
+	b := new(body)
+	b.attr("id", quote(aws.ToString(query.Id)))
+	b.optional("expression", query.Expression)
+	b.optional("label", query.Label)
+	b.optional("account_id", query.AccountId)
+	b.int("period", query.Period)
+	if query.ReturnData != nil {
+		b.attr("return_data", strconv.FormatBool(aws.ToBool(query.ReturnData)))
+	}
+
+	if stat := query.MetricStat; stat != nil && stat.Metric != nil {
+		metric := new(body)
+		metric.optional("metric_name", stat.Metric.MetricName)
+		metric.optional("namespace", stat.Metric.Namespace)
+		metric.int("period", stat.Period)
+		metric.optional("stat", stat.Stat)
+		metric.attrIf(stat.Unit != "", "unit", quote(string(stat.Unit)))
+		metric.object("dimensions", dimensions(stat.Metric.Dimensions))
+		b.block("metric", metric)
+	}
+
+	return b
+}
+
+func dimensions(dims []types.Dimension) map[string]string {
+	out := make(map[string]string, len(dims))
+	for _, dim := range dims {
+		out[aws.ToString(dim.Name)] = aws.ToString(dim.Value)
+	}
+
+	return out
+}
+
+// body holds the arguments and nested blocks of a block, in the order that they are written.
+type body struct {
+	items []item
+}
+
+// item is either an argument with an encoded value, an object argument, or a nested block.
+type item struct {
+	name   string
+	value  string
+	object map[string]string
+	block  *body
+}
+
+func (b *body) attr(name, value string) {
+	b.items = append(b.items, item{name: name, value: value})
+}
+
+func (b *body) attrIf(ok bool, name, value string) {
+	if ok {
+		b.attr(name, value)
+	}
+}
+
+func (b *body) optional(name string, value *string) {
+	b.attrIf(value != nil, name, quote(aws.ToString(value)))
+}
+
+func (b *body) int(name string, value *int32) {
+	b.attrIf(value != nil, name, strconv.Itoa(int(aws.ToInt32(value))))
+}
+
+func (b *body) list(name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quote(value)
+	}
+	b.attr(name, "["+strings.Join(quoted, ", ")+"]")
+}
+
+func (b *body) object(name string, values map[string]string) {
+	if len(values) > 0 {
+		b.items = append(b.items, item{name: name, object: values})
+	}
+}
+
+func (b *body) block(name string, block *body) {
+	b.items = append(b.items, item{name: name, block: block})
+}
+
+// write writes the body at the indent level, aligning the equals signs of consecutive arguments the way that
+// terraform fmt does. An object argument ends the group of arguments that it is aligned with.
+func (b *body) write(buf *bytes.Buffer, level int) {
+	indent := strings.Repeat("  ", level)
+	for i := 0; i < len(b.items); {
+		if block := b.items[i].block; block != nil {
+			fmt.Fprintf(buf, "\n%s%s {\n", indent, b.items[i].name)
+			block.write(buf, level+1)
+			fmt.Fprintf(buf, "%s}\n", indent)
+			i++
+			continue
+		}
+
+		end := i
+		width := 0
+		for end < len(b.items) && b.items[end].block == nil {
+			width = max(width, len(b.items[end].name))
+			end++
+			if b.items[end-1].object != nil {
+				break
+			}
+		}
+		for _, it := range b.items[i:end] {
+			if it.object == nil {
+				fmt.Fprintf(buf, "%s%-*s = %s\n", indent, width, it.name, it.value)
+				continue
+			}
+			fmt.Fprintf(buf, "%s%-*s = {\n", indent, width, it.name)
+			writeObject(buf, indent+"  ", it.object)
+			fmt.Fprintf(buf, "%s}\n", indent)
+		}
+		i = end
+	}
+}
+
+// writeObject writes the entries of an object argument ordered by key, with their equals signs aligned.
+func writeObject(buf *bytes.Buffer, indent string, object map[string]string) {
+	keys := make([]string, 0, len(object))
+	width := 0
+	for key := range object {
+		keys = append(keys, key)
+		width = max(width, len(quote(key)))
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%s%-*s = %s\n", indent, width, quote(key), quote(object[key]))
+	}
+}
+
+// quote returns s as an HCL string, escaping the ${ and %{ template sequences.
+func quote(s string) string {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	// strings always encode
+	_ = encoder.Encode(s)
+
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
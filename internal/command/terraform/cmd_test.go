@@ -0,0 +1,118 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCmd_Execute(t *testing.T) {
+	t.Parallel()
+
+	inputs := []*cloudwatch.PutMetricAlarmInput{
+		{
+			AlarmName:          aws.String("b ${var} errors"),
+			ComparisonOperator: types.ComparisonOperatorGreaterThanThreshold,
+			EvaluationPeriods:  aws.Int32(3),
+			Threshold:          aws.Float64(0.5),
+			Metrics: []types.MetricDataQuery{
+				{
+					Id:         aws.String("e1"),
+					Expression: aws.String("m1 / m2"),
+					ReturnData: aws.Bool(true),
+				},
+				{
+					Id:         aws.String("m1"),
+					ReturnData: aws.Bool(false),
+					MetricStat: &types.MetricStat{
+						Metric: &types.Metric{
+							MetricName: aws.String("Errors"),
+							Namespace:  aws.String("AWS/Lambda"),
+							Dimensions: []types.Dimension{{Name: aws.String("FunctionName"), Value: aws.String("fn")}},
+						},
+						Period: aws.Int32(60),
+						Stat:   aws.String("Sum"),
+					},
+				},
+			},
+		},
+		{
+			AlarmName:          aws.String("a alarm"),
+			ComparisonOperator: types.ComparisonOperatorLessThanThreshold,
+			EvaluationPeriods:  aws.Int32(1),
+			MetricName:         aws.String("Invocations"),
+			Namespace:          aws.String("AWS/Lambda"),
+			Period:             aws.Int32(60),
+			Statistic:          types.StatisticSum,
+			Threshold:          aws.Float64(1),
+			AlarmActions:       []string{"arn:aws:sns:us-east-1:123456789012:a", "arn:aws:sns:us-east-1:123456789012:b"},
+			Tags:               []types.Tag{{Key: aws.String("team"), Value: aws.String("payments")}},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, NewCreateCmd(inputs, buf).Execute(context.TODO()))
+
+	want := `resource "aws_cloudwatch_metric_alarm" "` + ResourceName("a alarm") + `" {
+  alarm_name          = "a alarm"
+  comparison_operator = "LessThanThreshold"
+  evaluation_periods  = 1
+  metric_name         = "Invocations"
+  namespace           = "AWS/Lambda"
+  period              = 60
+  statistic           = "Sum"
+  threshold           = 1
+  alarm_actions       = ["arn:aws:sns:us-east-1:123456789012:a", "arn:aws:sns:us-east-1:123456789012:b"]
+  tags                = {
+    "team" = "payments"
+  }
+}
+
+resource "aws_cloudwatch_metric_alarm" "` + ResourceName("b ${var} errors") + `" {
+  alarm_name          = "b $${var} errors"
+  comparison_operator = "GreaterThanThreshold"
+  evaluation_periods  = 3
+  threshold           = 0.5
+
+  metric_query {
+    id          = "e1"
+    expression  = "m1 / m2"
+    return_data = true
+  }
+
+  metric_query {
+    id          = "m1"
+    return_data = false
+
+    metric {
+      metric_name = "Errors"
+      namespace   = "AWS/Lambda"
+      period      = 60
+      stat        = "Sum"
+      dimensions  = {
+        "FunctionName" = "fn"
+      }
+    }
+  }
+}
+`
+	assert.Equal(t, want, buf.String())
+}
+
+func TestResourceName(t *testing.T) {
+	t.Parallel()
+
+	name := ResourceName("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue")
+
+	assert.Regexp(t, `^aws_sqs_approximatenumberofmessagesvisible_100_queuename_my_queue_[0-9a-f]{8}$`, name)
+	assert.Equal(t, name, ResourceName("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue"))
+	assert.NotEqual(t, name, ResourceName("AWS/SQS ApproximateNumberOfMessagesVisible < 100 QueueName=my-queue"))
+	assert.Regexp(t, `^alarm_5xx_errors_[0-9a-f]{8}$`, ResourceName("5xx errors"))
+	assert.Regexp(t, `^alarm_[0-9a-f]{8}$`, ResourceName("> !"))
+}
@@ -3,22 +3,104 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	awsarn "github.com/aws/aws-sdk-go-v2/aws/arn"
 )
 
-// Config is parsed data from flags, variables, or files. Well, just a file in this case.
+// DeleteStrategy values select how alarms are found for deletion. An empty DeleteStrategy uses DeleteStrategyTags.
+const (
+	// DeleteStrategyTags finds alarms to delete by their AWS_AUTO_ALARM_MANAGED and AWS_AUTO_ALARM_SOURCE_ARN tags.
+	DeleteStrategyTags = "tags"
+	// DeleteStrategyTemplate finds alarms to delete by rendering the alarm templates for the ARN.
+	DeleteStrategyTemplate = "template"
+)
+
+// Output values select the format that dry runs write the alarms in. An empty Output uses OutputJSON.
+const (
+	OutputJSON           = "json"
+	OutputCloudFormation = "cloudformation"
+	OutputTerraform      = "terraform"
+)
+
+// Outputs are the supported Output formats.
+var Outputs = []string{OutputJSON, OutputCloudFormation, OutputTerraform}
+
+// ValidOutput returns an error if the output is not empty or one of Outputs.
+func ValidOutput(output string) error {
+	if output != "" && !slices.Contains(Outputs, output) {
+		return fmt.Errorf("unknown output %q: use one of %s", output, strings.Join(Outputs, ", "))
+	}
+
+	return nil
+}
+
+// Config is parsed data from flags, variables, or files. See Loader for the order that they are applied.
 type Config struct {
-	DryRun       bool              `json:"dryRun"`
-	PrettyPrint  bool              `json:"prettyPrint"`
-	AlarmPrefix  string            `json:"alarmPrefix"`
-	ARN          string            `json:"arn"`
-	Delete       bool              `json:"delete"`
-	OKActions    []string          `json:"okActions"`
-	AlarmActions []string          `json:"alarmActions"`
-	Overrides    map[string]any    `json:"overrides"`
-	Tags         map[string]string `json:"tags"`
-	ParsedARN    awsarn.ARN
+	DryRun                  bool              `json:"dryRun"`
+	Plan                    bool              `json:"plan"`
+	PrettyPrint             bool              `json:"prettyPrint"`
+	AlarmPrefix             string            `json:"alarmPrefix"`
+	ARN                     string            `json:"arn"`
+	Delete                  bool              `json:"delete"`
+	DeleteStrategy          string            `json:"deleteStrategy"`
+	DisablePrune            bool              `json:"disablePrune"`
+	Output                  string            `json:"output"`
+	OKActions               []string          `json:"okActions"`
+	AlarmActions            []string          `json:"alarmActions"`
+	InsufficientDataActions []string          `json:"insufficientDataActions"`
+	Routes                  map[string]Route  `json:"routes"`
+	SeverityActions         map[string]Route  `json:"severityActions"`
+	Overrides               map[string]any    `json:"overrides"`
+	Include                 []string          `json:"include"`
+	Exclude                 []string          `json:"exclude"`
+	Tags                    map[string]string `json:"tags"`
+	PropagateTags           []string          `json:"propagateTags"`
+	MuteUntil               time.Time         `json:"muteUntil"`
+	ResourceTags            map[string]string `json:"-"`
+	TemplateSource          string            `json:"templateSource"`
+	Discover                *Discover         `json:"discover"`
+	ParsedARN               awsarn.ARN
+}
+
+// Discover selects the resources to run for by their tags, instead of a single ARN.
+type Discover struct {
+	// Tags that every resource must have, such as AWS_AUTO_ALARM_ENABLED=true.
+	Tags map[string]string `json:"tags"`
+	// Services limit the resource types, such as "sqs" or "lambda:function". When empty, all types are found.
+	Services []string `json:"services"`
+}
+
+// Clone returns a copy of the Config that does not share its slices or maps, so it can be changed by ApplyTags.
+func (c *Config) Clone() *Config {
+	cfg := *c
+	cfg.OKActions = slices.Clone(c.OKActions)
+	cfg.AlarmActions = slices.Clone(c.AlarmActions)
+	cfg.InsufficientDataActions = slices.Clone(c.InsufficientDataActions)
+	cfg.Routes = maps.Clone(c.Routes)
+	cfg.SeverityActions = maps.Clone(c.SeverityActions)
+	cfg.Overrides = maps.Clone(c.Overrides)
+	cfg.Include = slices.Clone(c.Include)
+	cfg.Exclude = slices.Clone(c.Exclude)
+	cfg.Tags = maps.Clone(c.Tags)
+	cfg.PropagateTags = slices.Clone(c.PropagateTags)
+	cfg.ResourceTags = maps.Clone(c.ResourceTags)
+	if c.Discover != nil {
+		cfg.Discover = &Discover{
+			Tags:     maps.Clone(c.Discover.Tags),
+			Services: slices.Clone(c.Discover.Services),
+		}
+	}
+
+	return &cfg
+}
+
+// Muted returns true if the alarm actions are muted at the time, because it is before MuteUntil.
+func (c *Config) Muted(now time.Time) bool {
+	return now.Before(c.MuteUntil)
 }
 
 func ParseARN(cfg *Config) error {
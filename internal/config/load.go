@@ -0,0 +1,200 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	kmaps "github.com/knadh/koanf/maps"
+	kjson "github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// Layers of a Loader, in the order that they are applied. A value from a later layer replaces the same value from an
+// earlier layer.
+const (
+	LayerDefaults = "defaults"
+	LayerFile     = "file"
+	LayerEnv      = "env"
+	LayerFlags    = "flags"
+)
+
+// EnvPrefix is the prefix of the environment variables that configure a Config. The rest of the variable name is the
+// Config field, the same as the AWS_AUTO_ALARM_* resource tags, such as AWS_AUTO_ALARM_ALARMACTIONS.
+const EnvPrefix = "AWS_AUTO_ALARM_"
+
+// delim separates the parts of a key, such as discover.tags.
+const delim = "."
+
+// defaults are the built-in values of the first layer.
+var defaults = map[string]any{
+	"dryRun":         false,
+	"plan":           false,
+	"prettyPrint":    false,
+	"delete":         false,
+	"deleteStrategy": DeleteStrategyTags,
+	"disablePrune":   false,
+}
+
+// fields are the Config fields by their JSON name, lower cased without underscores. Fields without a JSON name
+// cannot be loaded.
+var fields = configFields()
+
+// Loader loads a Config from layers and records which layer supplied each value.
+type Loader struct {
+	k       *koanf.Koanf
+	sources map[string]string
+}
+
+// NewLoader returns a Loader with the built-in defaults already loaded.
+func NewLoader() *Loader {
+	l := &Loader{
+		k:       koanf.New(delim),
+		sources: make(map[string]string),
+	}
+	// the defaults are static, so they always load
+	_ = l.Load(LayerDefaults, confmap.Provider(defaults, ""), nil)
+
+	return l
+}
+
+// Load merges the values from the provider into the Loader as the layer. Keys are matched to the Config fields
+// without regard to case, so a file can use "ARN" or "arn".
+func (l *Loader) Load(layer string, p koanf.Provider, pa koanf.Parser) error {
+	k := koanf.New(delim)
+	if err := k.Load(p, pa); err != nil {
+		return fmt.Errorf("unable to load %s config: %w", layer, err)
+	}
+
+	values := canonicalKeys(k.Raw())
+	if err := l.k.Load(confmap.Provider(values, ""), nil); err != nil {
+		return fmt.Errorf("unable to merge %s config: %w", layer, err)
+	}
+
+	flat, _ := kmaps.Flatten(values, nil, delim)
+	for key := range flat {
+		l.sources[key] = layer
+	}
+
+	return nil
+}
+
+// LoadFile loads the file layer from a JSON, YAML or TOML file, chosen by the file extension.
+func (l *Loader) LoadFile(path string) error {
+	var pa koanf.Parser
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		pa = kjson.Parser()
+	case ".yaml", ".yml":
+		pa = yaml.Parser()
+	case ".toml":
+		pa = toml.Parser()
+	default:
+		return fmt.Errorf("unsupported config file %s: use a .json, .yaml, .yml or .toml file", path)
+	}
+
+	return l.Load(LayerFile, file.Provider(path), pa)
+}
+
+// LoadEnv loads the env layer from the AWS_AUTO_ALARM_* environment variables. List values are separated by commas
+// and map values are JSON, the same as the resource tags, and AWS_AUTO_ALARM_ACTIONS_<SEVERITY> variables set the
+// alarm actions of a severity. Variables that do not match a Config field are ignored.
+func (l *Loader) LoadEnv() error {
+	var errs []error
+	p := env.ProviderWithValue(EnvPrefix, delim, func(key string, value string) (string, any) {
+		severity, actions, ok, err := severityActions(key, value)
+		if err != nil {
+			errs = append(errs, err)
+			return "", nil
+		}
+		if ok {
+			return strings.Join([]string{"severityActions", severity, "alarmActions"}, delim), actions
+		}
+
+		field, ok := fields[normalize(strings.TrimPrefix(key, EnvPrefix))]
+		if !ok {
+			return "", nil
+		}
+
+		name := jsonName(field)
+		switch field.Type.Kind() {
+		case reflect.Slice:
+			return name, strings.Split(value, ",")
+		case reflect.Map, reflect.Pointer:
+			parsed := make(map[string]any)
+			if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+				errs = append(errs, fmt.Errorf("unable to parse %s: %w", key, err))
+				return "", nil
+			}
+			return name, parsed
+		default:
+			return name, value
+		}
+	})
+
+	if err := l.Load(LayerEnv, p, nil); err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+// Config returns the Config from the merged layers.
+func (l *Loader) Config() (*Config, error) {
+	cfg := new(Config)
+	if err := l.k.UnmarshalWithConf("", cfg, koanf.UnmarshalConf{Tag: "json"}); err != nil {
+		return nil, fmt.Errorf("unable to decode config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Sources returns the layer that supplied each value, by its key, such as "alarmPrefix" or "discover.services".
+func (l *Loader) Sources() map[string]string {
+	return maps.Clone(l.sources)
+}
+
+// canonicalKeys renames the top level keys that match a Config field to the JSON name of the field, so that the
+// layers merge into the same key.
+func canonicalKeys(values map[string]any) map[string]any {
+	out := make(map[string]any, len(values))
+	for key, value := range values {
+		if field, ok := fields[normalize(key)]; ok {
+			key = jsonName(field)
+		}
+		out[key] = value
+	}
+
+	return out
+}
+
+func configFields() map[string]reflect.StructField {
+	t := reflect.TypeOf(Config{})
+	out := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name := jsonName(field); name != "" && name != "-" {
+			out[normalize(name)] = field
+		}
+	}
+
+	return out
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+func normalize(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_LoadFile(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		fileName string
+		contents string
+		wantErr  bool
+	}{
+		"json": {
+			fileName: "config.json",
+			contents: `{"ARN": "arn:aws:sqs:us-east-1:123456789012:test-queue", "alarmPrefix": "file", "alarmActions": ["arn:aws:sns:us-east-1:123456789012:alerts"]}`,
+		},
+		"yaml": {
+			fileName: "config.yaml",
+			contents: "arn: arn:aws:sqs:us-east-1:123456789012:test-queue\nalarmPrefix: file\nalarmActions:\n  - arn:aws:sns:us-east-1:123456789012:alerts\n",
+		},
+		"toml": {
+			fileName: "config.toml",
+			contents: "arn = \"arn:aws:sqs:us-east-1:123456789012:test-queue\"\nalarm_prefix = \"file\"\nalarmActions = [\"arn:aws:sns:us-east-1:123456789012:alerts\"]\n",
+		},
+		"unsupported extension": {
+			fileName: "config.ini",
+			contents: "arn=arn:aws:sqs:us-east-1:123456789012:test-queue",
+			wantErr:  true,
+		},
+		"invalid file": {
+			fileName: "config.json",
+			contents: "not-json",
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			require.NoError(t, os.WriteFile(path, []byte(tc.contents), 0o600))
+
+			loader := NewLoader()
+			err := loader.LoadFile(path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			cfg, err := loader.Config()
+			require.NoError(t, err)
+
+			assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:test-queue", cfg.ARN)
+			assert.Equal(t, "file", cfg.AlarmPrefix)
+			assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:alerts"}, cfg.AlarmActions)
+			assert.Equal(t, DeleteStrategyTags, cfg.DeleteStrategy)
+		})
+	}
+}
+
+func TestLoader_Sources(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"alarmPrefix": "file", "deleteStrategy": "template", "overrides": {"QueueName": "other"}}`), 0o600))
+
+	loader := NewLoader()
+	require.NoError(t, loader.LoadFile(path))
+	require.NoError(t, loader.Load(LayerFlags, confmap.Provider(map[string]any{
+		"alarmPrefix":    "flag",
+		"discover.tags":  map[string]string{"AWS_AUTO_ALARM_ENABLED": "true"},
+		"disablePrune":   true,
+		"templateSource": "./templates",
+	}, "."), nil))
+
+	cfg, err := loader.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, "flag", cfg.AlarmPrefix)
+	assert.Equal(t, DeleteStrategyTemplate, cfg.DeleteStrategy)
+	assert.True(t, cfg.DisablePrune)
+	assert.Equal(t, map[string]any{"QueueName": "other"}, cfg.Overrides)
+	assert.Equal(t, &Discover{Tags: map[string]string{"AWS_AUTO_ALARM_ENABLED": "true"}}, cfg.Discover)
+
+	sources := loader.Sources()
+	assert.Equal(t, LayerFlags, sources["alarmPrefix"])
+	assert.Equal(t, LayerFile, sources["deleteStrategy"])
+	assert.Equal(t, LayerFile, sources["overrides.QueueName"])
+	assert.Equal(t, LayerFlags, sources["discover.tags"])
+	assert.Equal(t, LayerFlags, sources["disablePrune"])
+	assert.Equal(t, LayerDefaults, sources["dryRun"])
+}
+
+// TestLoader_LoadEnv cannot run in parallel because it sets environment variables.
+func TestLoader_LoadEnv(t *testing.T) {
+	t.Setenv("AWS_AUTO_ALARM_ALARMPREFIX", "env")
+	t.Setenv("AWS_AUTO_ALARM_ALARMACTIONS", "arn:aws:sns:us-east-1:123456789012:a,arn:aws:sns:us-east-1:123456789012:b")
+	t.Setenv("AWS_AUTO_ALARM_DRYRUN", "true")
+	t.Setenv("AWS_AUTO_ALARM_OVERRIDES", `{"QueueName": "other"}`)
+	t.Setenv("AWS_AUTO_ALARM_TEMPLATE_SOURCE", "s3://bucket/templates")
+	t.Setenv("AWS_AUTO_ALARM_CONCURRENCY", "8")
+	t.Setenv("AWS_AUTO_ALARM_ACTIONS_CRITICAL", "arn:aws:sns:us-east-1:123456789012:pager")
+	t.Setenv("AWS_AUTO_ALARM_MUTE_UNTIL", "2026-11-01T06:00:00Z")
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"alarmPrefix": "file", "okActions": ["arn:aws:sns:us-east-1:123456789012:ok"]}`), 0o600))
+
+	loader := NewLoader()
+	require.NoError(t, loader.LoadFile(path))
+	require.NoError(t, loader.LoadEnv())
+
+	cfg, err := loader.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, "env", cfg.AlarmPrefix)
+	assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:a", "arn:aws:sns:us-east-1:123456789012:b"}, cfg.AlarmActions)
+	assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:ok"}, cfg.OKActions)
+	assert.True(t, cfg.DryRun)
+	assert.Equal(t, map[string]any{"QueueName": "other"}, cfg.Overrides)
+	assert.Equal(t, "s3://bucket/templates", cfg.TemplateSource)
+	assert.Equal(t, map[string]Route{
+		SeverityCritical: {AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:pager"}},
+	}, cfg.SeverityActions)
+	assert.Equal(t, time.Date(2026, time.November, 1, 6, 0, 0, 0, time.UTC), cfg.MuteUntil)
+
+	sources := loader.Sources()
+	assert.Equal(t, LayerEnv, sources["alarmPrefix"])
+	assert.Equal(t, LayerFile, sources["okActions"])
+	assert.NotContains(t, sources, "concurrency")
+
+	t.Run("invalid JSON returns an error", func(t *testing.T) {
+		t.Setenv("AWS_AUTO_ALARM_TAGS", "not-json")
+
+		assert.ErrorContains(t, NewLoader().LoadEnv(), "unable to parse AWS_AUTO_ALARM_TAGS")
+	})
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Route is the actions that a routing alias, such as team-payments-critical, resolves to. Aliases are set in
+// Config.Routes and can be used in place of ARNs in the action lists.
+type Route struct {
+	AlarmActions            []string `json:"alarmActions"`
+	OKActions               []string `json:"okActions"`
+	InsufficientDataActions []string `json:"insufficientDataActions"`
+}
+
+// ResolveRoutes replaces the routing aliases in the action lists of the Config, and of each of its SeverityActions, with
+// the actions of their Route. An alias in any of the lists adds all of the actions of its Route, each to the matching
+// list. Values that are ARNs are kept as they are, and an alias that is not in Config.Routes returns an error.
+func ResolveRoutes(cfg *Config) error {
+	unknown := make([]string, 0)
+
+	resolved := resolveRoute(cfg.Routes, Route{
+		AlarmActions:            cfg.AlarmActions,
+		OKActions:               cfg.OKActions,
+		InsufficientDataActions: cfg.InsufficientDataActions,
+	}, &unknown)
+
+	severities := make(map[string]Route, len(cfg.SeverityActions))
+	for severity, route := range cfg.SeverityActions {
+		if err := ValidSeverity(severity); err != nil {
+			return err
+		}
+		severities[severity] = resolveRoute(cfg.Routes, route, &unknown)
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown action aliases: %s", strings.Join(unknown, ", "))
+	}
+
+	cfg.AlarmActions = resolved.AlarmActions
+	cfg.OKActions = resolved.OKActions
+	cfg.InsufficientDataActions = resolved.InsufficientDataActions
+	if cfg.SeverityActions != nil {
+		cfg.SeverityActions = severities
+	}
+
+	return nil
+}
+
+// resolveRoute returns the route with its aliases resolved, and adds the aliases that are not in routes to unknown.
+func resolveRoute(routes map[string]Route, route Route, unknown *[]string) Route {
+	alarm, ok, insufficient := make([]string, 0), make([]string, 0), make([]string, 0)
+
+	resolve := func(actions []string, dest *[]string) {
+		for _, action := range actions {
+			if isARN(action) {
+				*dest = append(*dest, action)
+				continue
+			}
+
+			found, exists := routes[action]
+			if !exists {
+				*unknown = append(*unknown, action)
+				continue
+			}
+			alarm = append(alarm, found.AlarmActions...)
+			ok = append(ok, found.OKActions...)
+			insufficient = append(insufficient, found.InsufficientDataActions...)
+		}
+	}
+
+	resolve(route.AlarmActions, &alarm)
+	resolve(route.OKActions, &ok)
+	resolve(route.InsufficientDataActions, &insufficient)
+
+	return Route{
+		AlarmActions:            actionList(route.AlarmActions, alarm),
+		OKActions:               actionList(route.OKActions, ok),
+		InsufficientDataActions: actionList(route.InsufficientDataActions, insufficient),
+	}
+}
+
+func isARN(action string) bool {
+	return strings.HasPrefix(action, "arn:")
+}
+
+// actionList returns the resolved actions in order without duplicates. An unset list stays unset when nothing was
+// resolved into it.
+func actionList(original, resolved []string) []string {
+	if original == nil && len(resolved) == 0 {
+		return nil
+	}
+
+	out := make([]string, 0, len(resolved))
+	for _, action := range resolved {
+		if !slices.Contains(out, action) {
+			out = append(out, action)
+		}
+	}
+
+	return out
+}
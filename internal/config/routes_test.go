@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveRoutes(t *testing.T) {
+	t.Parallel()
+
+	routes := map[string]Route{
+		"team-a": {
+			AlarmActions:            []string{"arn:aws:sns:us-east-1:123456789012:team-a"},
+			OKActions:               []string{"arn:aws:sns:us-east-1:123456789012:team-a"},
+			InsufficientDataActions: []string{"arn:aws:sns:us-east-1:123456789012:team-a-tickets"},
+		},
+		"team-b": {
+			AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:team-b", "arn:aws:sns:us-east-1:123456789012:team-a"},
+		},
+	}
+
+	cases := map[string]struct {
+		cfg     *Config
+		want    *Config
+		wantErr string
+	}{
+		"ARNs are kept": {
+			cfg: &Config{
+				AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:alerts"},
+			},
+			want: &Config{
+				AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:alerts"},
+			},
+		},
+		"aliases add every list of their route without duplicates": {
+			cfg: &Config{
+				Routes:       routes,
+				AlarmActions: []string{"team-a", "team-b"},
+			},
+			want: &Config{
+				Routes:                  routes,
+				AlarmActions:            []string{"arn:aws:sns:us-east-1:123456789012:team-a", "arn:aws:sns:us-east-1:123456789012:team-b"},
+				OKActions:               []string{"arn:aws:sns:us-east-1:123456789012:team-a"},
+				InsufficientDataActions: []string{"arn:aws:sns:us-east-1:123456789012:team-a-tickets"},
+			},
+		},
+		"unknown aliases return an error": {
+			cfg: &Config{
+				Routes:       routes,
+				AlarmActions: []string{"team-c"},
+				OKActions:    []string{"team-a", "team-d"},
+			},
+			wantErr: "unknown action aliases: team-c, team-d",
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ResolveRoutes(tc.cfg)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, tc.cfg)
+		})
+	}
+}
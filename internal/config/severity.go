@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severity tiers that a template can declare for its alarms.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Severities are the supported severity tiers.
+var Severities = []string{SeverityCritical, SeverityWarning, SeverityInfo}
+
+// SeverityActionsPrefix is the prefix of the tags and environment variables that set the alarm actions for a severity,
+// such as AWS_AUTO_ALARM_ACTIONS_CRITICAL.
+const SeverityActionsPrefix = "AWS_AUTO_ALARM_ACTIONS_"
+
+// ValidSeverity returns an error if the severity is not one of Severities.
+func ValidSeverity(severity string) error {
+	if !slices.Contains(Severities, severity) {
+		return fmt.Errorf("unknown severity %q: use one of %s", severity, strings.Join(Severities, ", "))
+	}
+
+	return nil
+}
+
+// severityActions returns the severity and its alarm actions for a SeverityActionsPrefix key, such as
+// AWS_AUTO_ALARM_ACTIONS_CRITICAL. ok is false for any other key.
+func severityActions(key, value string) (severity string, actions []string, ok bool, err error) {
+	suffix, found := strings.CutPrefix(key, SeverityActionsPrefix)
+	if !found {
+		return "", nil, false, nil
+	}
+
+	severity = strings.ToLower(suffix)
+	if err = ValidSeverity(severity); err != nil {
+		return "", nil, true, fmt.Errorf("unable to parse %s: %w", key, err)
+	}
+
+	return severity, strings.Split(value, ","), true, nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Prefixes of the tag keys that are never copied onto alarms.
+const (
+	automationTagPrefix = "AWS_AUTO_ALARM_"
+	awsTagPrefix        = "aws:"
+)
+
+// ApplyTags sets the fields of the Config from the AWS_AUTO_ALARM_* tags of a resource. Tags that are not present
+// leave their fields unchanged. The other tags are kept as the ResourceTags, for PropagateTags.
+func ApplyTags(cfg *Config, tags map[string]string) error {
+	cfg.ResourceTags = resourceTags(tags)
+
+	// This is ugly but we can fix it later
+	for key, value := range tags {
+		severity, actions, ok, err := severityActions(key, value)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if cfg.SeverityActions == nil {
+				cfg.SeverityActions = make(map[string]Route)
+			}
+			cfg.SeverityActions[severity] = Route{AlarmActions: actions}
+			continue
+		}
+
+		switch key {
+		case "AWS_AUTO_ALARM_ALARMPREFIX":
+			cfg.AlarmPrefix = value
+		case "AWS_AUTO_ALARM_DRYRUN":
+			cfg.DryRun = value == "true"
+		case "AWS_AUTO_ALARM_PLAN":
+			cfg.Plan = value == "true"
+		case "AWS_AUTO_ALARM_DELETESTRATEGY":
+			cfg.DeleteStrategy = value
+		case "AWS_AUTO_ALARM_DISABLEPRUNE":
+			cfg.DisablePrune = value == "true"
+		case "AWS_AUTO_ALARM_ALARMACTIONS":
+			cfg.AlarmActions = strings.Split(value, ",")
+		case "AWS_AUTO_ALARM_OKACTIONS":
+			cfg.OKActions = strings.Split(value, ",")
+		case "AWS_AUTO_ALARM_INSUFFICIENTDATAACTIONS":
+			cfg.InsufficientDataActions = strings.Split(value, ",")
+		case "AWS_AUTO_ALARM_INCLUDE":
+			cfg.Include = strings.Split(value, ",")
+		case "AWS_AUTO_ALARM_EXCLUDE":
+			cfg.Exclude = strings.Split(value, ",")
+		case "AWS_AUTO_ALARM_OVERRIDES":
+			if err := json.Unmarshal([]byte(value), &cfg.Overrides); err != nil {
+				return fmt.Errorf("unable to parse AWS_AUTO_ALARM_OVERRIDES: %w", err)
+			}
+		case "AWS_AUTO_ALARM_MUTE_UNTIL":
+			muteUntil, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return fmt.Errorf("unable to parse AWS_AUTO_ALARM_MUTE_UNTIL: %w", err)
+			}
+			cfg.MuteUntil = muteUntil
+		case "AWS_AUTO_ALARM_PROPAGATETAGS":
+			cfg.PropagateTags = strings.Split(value, ",")
+		case "AWS_AUTO_ALARM_TAGS":
+			if err := json.Unmarshal([]byte(value), &cfg.Tags); err != nil {
+				return fmt.Errorf("unable to parse AWS_AUTO_ALARM_TAGS: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceTags returns the tags that can be copied onto alarms, which are all but the AWS_AUTO_ALARM_* and aws:
+// tags, or nil if there are none.
+func resourceTags(tags map[string]string) map[string]string {
+	var out map[string]string
+	for key, value := range tags {
+		if strings.HasPrefix(key, automationTagPrefix) || strings.HasPrefix(strings.ToLower(key), awsTagPrefix) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[key] = value
+	}
+
+	return out
+}
+
+// PropagatedTags returns the ResourceTags that match the PropagateTags of the Config. An entry that ends with * matches
+// the tag keys that start with the rest of the entry, such as cost-* for cost-center. Other entries match the tag key
+// exactly.
+func PropagatedTags(cfg *Config) map[string]string {
+	out := make(map[string]string)
+	for key, value := range cfg.ResourceTags {
+		for _, entry := range cfg.PropagateTags {
+			prefix, isPrefix := strings.CutSuffix(entry, "*")
+			if key == entry || (isPrefix && strings.HasPrefix(key, prefix)) {
+				out[key] = value
+				break
+			}
+		}
+	}
+
+	return out
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/rs/zerolog/log"
@@ -21,14 +20,14 @@ type tagChangeDetail struct {
 	Tags           map[string]string `json:"tags"`
 }
 
-// NewConfig creates a new Config from an EventBridge event.
+// NewConfig creates a new Config from an EventBridge event, starting from a copy of the defaults when they are set.
 // The event must have a single resource ARN and a detail field that
 // contains the tag change details.
-func NewConfig(ctx context.Context, event *events.EventBridgeEvent) (*config.Config, error) {
+func NewConfig(ctx context.Context, defaults *config.Config, event *events.EventBridgeEvent) (*config.Config, error) {
 	logger := log.Ctx(ctx)
 
 	logger.Debug().Msg("parsing cfg from event")
-	cfg := new(config.Config)
+	cfg := baseConfig(defaults)
 
 	if len(event.Resources) == 0 {
 		return nil, fmt.Errorf("no resources in event")
@@ -52,56 +51,53 @@ func NewConfig(ctx context.Context, event *events.EventBridgeEvent) (*config.Con
 	return cfg, nil
 }
 
+// newResourceConfig creates a new Config from the current tags of a resource, as found by a sweep.
+// The config is always an upsert, since only enabled resources are swept.
+func newResourceConfig(ctx context.Context, defaults *config.Config, resourceARN string, tags map[string]string) (*config.Config, error) {
+	cfg := baseConfig(defaults)
+	cfg.ARN = resourceARN
+
+	if err := config.ParseARN(cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse ARN: %w", err)
+	}
+
+	if err := parseDetail(ctx, cfg, &tagChangeDetail{Tags: tags}); err != nil {
+		return nil, fmt.Errorf("unable to parse tags: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// baseConfig returns a copy of the defaults, or an empty Config when there are none.
+func baseConfig(defaults *config.Config) *config.Config {
+	if defaults == nil {
+		return new(config.Config)
+	}
+
+	return defaults.Clone()
+}
+
 func parseDetail(ctx context.Context, cfg *config.Config, detail *tagChangeDetail) error {
 	logger := log.Ctx(ctx)
 	logger.Debug().Interface("detail", detail).Msg("processing tag change")
 
 	cfg.Delete = isDeleteAction(detail)
 
-	parseDetailTags(detail.Tags, cfg)
-
-	alarmActions(detail, cfg)
-
-	if overrides, ok := detail.Tags["AWS_AUTO_ALARM_OVERRIDES"]; ok {
-		if err := json.Unmarshal([]byte(overrides), &cfg.Overrides); err != nil {
-			return err
-		}
+	if err := config.ApplyTags(cfg, detail.Tags); err != nil {
+		return err
 	}
 
-	if tags, ok := detail.Tags["AWS_AUTO_ALARM_TAGS"]; ok {
-		if err := json.Unmarshal([]byte(tags), &cfg.Tags); err != nil {
-			return err
-		}
+	if err := config.ResolveRoutes(cfg); err != nil {
+		return err
 	}
 
 	logger.Debug().Interface("config", cfg).Msg("configuration complete")
 	return nil
 }
 
-func parseDetailTags(detailTags map[string]string, cfg *config.Config) {
-	// This is ugly but we can fix it later
-	for key, value := range detailTags {
-		switch key {
-		case "AWS_AUTO_ALARM_ALARMPREFIX":
-			cfg.AlarmPrefix = value
-		case "AWS_AUTO_ALARM_DRYRUN":
-			cfg.DryRun = value == "true"
-		}
-	}
-}
-
 func isDeleteAction(detail *tagChangeDetail) bool {
 	enabledIsChanged := slices.Contains(detail.ChangedTagKeys, "AWS_AUTO_ALARM_ENABLED")
 	_, enabledIsPresent := detail.Tags["AWS_AUTO_ALARM_ENABLED"]
 
 	return enabledIsChanged && !enabledIsPresent
 }
-
-func alarmActions(detail *tagChangeDetail, cfg *config.Config) {
-	if alarmActionsStr, ok := detail.Tags["AWS_AUTO_ALARM_ALARMACTIONS"]; ok {
-		cfg.AlarmActions = strings.Split(alarmActionsStr, ",")
-	}
-	if okActionsStr, ok := detail.Tags["AWS_AUTO_ALARM_OKACTIONS"]; ok {
-		cfg.OKActions = strings.Split(okActionsStr, ",")
-	}
-}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
@@ -32,14 +33,14 @@ func TestNewConfig(t *testing.T) {
 
 	t.Run("no resources in event returns error", func(t *testing.T) {
 
-		_, err = NewConfig(ctx, event)
+		_, err = NewConfig(ctx, nil, event)
 		assert.Error(t, err)
 	})
 
 	t.Run("unable to parse ARN returns error", func(t *testing.T) {
 
 		event.Resources = []string{"invalid-arn"}
-		_, err = NewConfig(ctx, event)
+		_, err = NewConfig(ctx, nil, event)
 		assert.Error(t, err)
 	})
 
@@ -47,7 +48,7 @@ func TestNewConfig(t *testing.T) {
 
 		event.Resources = []string{"arn:aws:sqs:us-east-1:123456789012:test-queue"}
 		event.Detail = []byte("invalid-detail")
-		_, err = NewConfig(ctx, event)
+		_, err = NewConfig(ctx, nil, event)
 		assert.Error(t, err)
 	})
 
@@ -55,7 +56,7 @@ func TestNewConfig(t *testing.T) {
 
 		event.Resources = []string{"arn:aws:sqs:us-east-1:123456789012:test-queue"}
 		event.Detail = detailBytes
-		cfg, err := NewConfig(ctx, event)
+		cfg, err := NewConfig(ctx, nil, event)
 		assert.NoError(t, err)
 
 		wanted := &config.Config{
@@ -71,6 +72,23 @@ func TestNewConfig(t *testing.T) {
 		}
 		assert.Equal(t, wanted, cfg)
 	})
+
+	t.Run("tags are applied on top of the defaults", func(t *testing.T) {
+
+		defaults := &config.Config{
+			AlarmPrefix:  "default",
+			AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:alerts"},
+		}
+
+		event.Resources = []string{"arn:aws:sqs:us-east-1:123456789012:test-queue"}
+		event.Detail = detailBytes
+		cfg, err := NewConfig(ctx, defaults, event)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "test", cfg.AlarmPrefix)
+		assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:alerts"}, cfg.AlarmActions)
+		assert.Equal(t, "default", defaults.AlarmPrefix, "the defaults are not changed")
+	})
 }
 
 func Test_parseDetail(t *testing.T) {
@@ -96,6 +114,7 @@ func Test_parseDetail(t *testing.T) {
 					Tags: map[string]string{
 						"AWS_AUTO_ALARM_ALARMPREFIX": "test",
 						"AWS_AUTO_ALARM_DRYRUN":      "true",
+						"AWS_AUTO_ALARM_PLAN":        "true",
 					},
 				}
 			},
@@ -107,6 +126,7 @@ func Test_parseDetail(t *testing.T) {
 			want: &config.Config{
 				AlarmPrefix: "test",
 				DryRun:      true,
+				Plan:        true,
 				ParsedARN:   defaultQueueARN,
 			},
 		},
@@ -127,16 +147,35 @@ func Test_parseDetail(t *testing.T) {
 				}
 			},
 			want: &config.Config{
-				Delete:    true,
-				ParsedARN: defaultQueueARN,
+				Delete:       true,
+				ParsedARN:    defaultQueueARN,
+				ResourceTags: map[string]string{"FOO": "BAR"},
+			},
+		},
+		"delete strategy is configured": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_DELETESTRATEGY": "template",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
+			},
+			want: &config.Config{
+				DeleteStrategy: "template",
+				ParsedARN:      defaultQueueARN,
 			},
 		},
 		"actions are configured": {
 			given: func(t testing.TB) *tagChangeDetail {
 				return &tagChangeDetail{
 					Tags: map[string]string{
-						"AWS_AUTO_ALARM_OKACTIONS":    "sns1,sns2",
-						"AWS_AUTO_ALARM_ALARMACTIONS": "sns3",
+						"AWS_AUTO_ALARM_OKACTIONS":    "arn:aws:sns:us-east-1:123456789012:sns1,arn:aws:sns:us-east-1:123456789012:sns2",
+						"AWS_AUTO_ALARM_ALARMACTIONS": "arn:aws:sns:us-east-1:123456789012:sns3",
 					},
 				}
 			},
@@ -148,9 +187,95 @@ func Test_parseDetail(t *testing.T) {
 			want: &config.Config{
 				Delete:       false,
 				ParsedARN:    defaultQueueARN,
-				OKActions:    []string{"sns1", "sns2"},
-				AlarmActions: []string{"sns3"},
+				OKActions:    []string{"arn:aws:sns:us-east-1:123456789012:sns1", "arn:aws:sns:us-east-1:123456789012:sns2"},
+				AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:sns3"},
+			},
+		},
+		"action aliases are resolved": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_ALARMACTIONS": "team-payments-critical,arn:aws:sns:us-east-1:123456789012:sns3",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+					Routes: map[string]config.Route{
+						"team-payments-critical": {
+							AlarmActions:            []string{"arn:aws:sns:us-east-1:123456789012:pager"},
+							OKActions:               []string{"arn:aws:sns:us-east-1:123456789012:pager"},
+							InsufficientDataActions: []string{"arn:aws:sns:us-east-1:123456789012:tickets"},
+						},
+					},
+				}
+			},
+			want: &config.Config{
+				ParsedARN: defaultQueueARN,
+				Routes: map[string]config.Route{
+					"team-payments-critical": {
+						AlarmActions:            []string{"arn:aws:sns:us-east-1:123456789012:pager"},
+						OKActions:               []string{"arn:aws:sns:us-east-1:123456789012:pager"},
+						InsufficientDataActions: []string{"arn:aws:sns:us-east-1:123456789012:tickets"},
+					},
+				},
+				AlarmActions:            []string{"arn:aws:sns:us-east-1:123456789012:pager", "arn:aws:sns:us-east-1:123456789012:sns3"},
+				OKActions:               []string{"arn:aws:sns:us-east-1:123456789012:pager"},
+				InsufficientDataActions: []string{"arn:aws:sns:us-east-1:123456789012:tickets"},
+			},
+		},
+		"severity actions are configured": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_ACTIONS_CRITICAL": "arn:aws:sns:us-east-1:123456789012:pager",
+						"AWS_AUTO_ALARM_ACTIONS_WARNING":  "arn:aws:sns:us-east-1:123456789012:chat",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
+			},
+			want: &config.Config{
+				ParsedARN: defaultQueueARN,
+				SeverityActions: map[string]config.Route{
+					config.SeverityCritical: {AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:pager"}},
+					config.SeverityWarning:  {AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:chat"}},
+				},
+			},
+		},
+		"unknown severity returns an error": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_ACTIONS_URGENT": "arn:aws:sns:us-east-1:123456789012:pager",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
 			},
+			wantErr: true,
+		},
+		"unknown action aliases return an error": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_ALARMACTIONS": "team-unknown",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
+			},
+			wantErr: true,
 		},
 		"overrides are configured": {
 			given: func(t testing.TB) *tagChangeDetail {
@@ -173,6 +298,47 @@ func Test_parseDetail(t *testing.T) {
 				},
 			},
 		},
+		"template selection is configured": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_INCLUDE": "messages-visible,dlq-messages-visible",
+						"AWS_AUTO_ALARM_EXCLUDE": "sqs/dlq-messages-visible",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
+			},
+			want: &config.Config{
+				ParsedARN: defaultQueueARN,
+				Include:   []string{"messages-visible", "dlq-messages-visible"},
+				Exclude:   []string{"sqs/dlq-messages-visible"},
+			},
+		},
+		"tag propagation is configured": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_PROPAGATETAGS": "team,cost-*",
+						"team":                         "payments",
+						"aws:cloudformation:stack-id":  "stack",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
+			},
+			want: &config.Config{
+				ParsedARN:     defaultQueueARN,
+				PropagateTags: []string{"team", "cost-*"},
+				ResourceTags:  map[string]string{"team": "payments"},
+			},
+		},
 		"tags are configured": {
 			given: func(t testing.TB) *tagChangeDetail {
 				return &tagChangeDetail{
@@ -209,6 +375,39 @@ func Test_parseDetail(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		"mute until is configured": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_MUTE_UNTIL": "2026-11-01T06:00:00Z",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
+			},
+			want: &config.Config{
+				ParsedARN: defaultQueueARN,
+				MuteUntil: time.Date(2026, time.November, 1, 6, 0, 0, 0, time.UTC),
+			},
+		},
+		"invalid mute until returns error": {
+			given: func(t testing.TB) *tagChangeDetail {
+				return &tagChangeDetail{
+					Tags: map[string]string{
+						"AWS_AUTO_ALARM_MUTE_UNTIL": "tomorrow",
+					},
+				}
+			},
+			cfg: func(t testing.TB) *config.Config {
+				return &config.Config{
+					ParsedARN: defaultQueueARN,
+				}
+			},
+			wantErr: true,
+		},
 		"invalid tags returns error": {
 			given: func(t testing.TB) *tagChangeDetail {
 				return &tagChangeDetail{
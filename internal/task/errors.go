@@ -0,0 +1,55 @@
+package task
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+
+	"github.com/akijowski/aws-auto-alarm/internal/template"
+)
+
+// permanentError wraps an error that will fail again if the message is redelivered, such as a malformed event or an
+// unsupported service.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// isRetryable reports whether the SQS message that caused err should be redelivered.
+// Errors marked as permanent, invalid template overrides and client API errors, other than throttling, are not retried.
+// Anything else, such as network errors, is assumed to be transient.
+func isRetryable(err error) bool {
+	var pErr *permanentError
+	if errors.As(err, &pErr) {
+		return false
+	}
+
+	var cErr *template.ConfigError
+	if errors.As(err, &cErr) {
+		return false
+	}
+
+	if retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorFault() == smithy.FaultClient {
+		return false
+	}
+
+	return true
+}
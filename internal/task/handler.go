@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"slices"
+	"sync"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambdacontext"
@@ -13,19 +15,75 @@ import (
 
 	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
 	"github.com/akijowski/aws-auto-alarm/internal/command"
+	cmdcw "github.com/akijowski/aws-auto-alarm/internal/command/cloudwatch"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
 	"github.com/akijowski/aws-auto-alarm/internal/template"
+	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
 )
 
 const (
 	eventbridgeEventSource     = "aws.tag"
 	eventbridgeEventDetailType = "Tag Change on Resource"
+
+	scheduledEventSource     = "aws.events"
+	scheduledEventDetailType = "Scheduled Event"
+
+	// unmuteAction is the action in the detail of the events of the unmute schedule. See AlarmHandler.Unmute.
+	unmuteAction = "unmute"
 )
 
+// scheduledDetail is the detail of a scheduled event. Events of the sweep schedule have an empty detail.
+type scheduledDetail struct {
+	Action string `json:"action"`
+}
+
+// DefaultConcurrency is the number of SQS records handled at once when AlarmHandler.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// ConcurrencyEnvVar is the environment variable that holds the number of SQS records handled at once.
+const ConcurrencyEnvVar = "AWS_AUTO_ALARM_CONCURRENCY"
+
 type AlarmHandler struct {
 	MetricAPI   autoalarm.MetricAlarmAPI
 	ResourceAPI autoalarm.GetResourcesAPI
+	TableAPI    autoalarm.DescribeTableAPI
+	FunctionAPI autoalarm.GetFunctionAPI
+	QueueAPI    autoalarm.QueueAttributesAPI
+	// Templates holds external alarm templates. When nil, only the embedded templates are used.
+	Templates fs.FS
+	// Concurrency is the number of SQS records handled at once. When zero, DefaultConcurrency is used.
+	Concurrency int
+	// PutOptions configure how alarms are put to CloudWatch. When empty, the command defaults are used.
+	PutOptions []func(*cmdcw.CreateOptions)
+	// Defaults is the config that every resource starts from before its tags are applied, such as default alarm
+	// actions loaded from the environment. When nil, resources start from an empty config.
+	Defaults *config.Config
 }
 
+// Route dispatches a Lambda payload to Sweep for scheduled EventBridge events, to Unmute for scheduled events with the
+// unmute action in their detail, and to Handle for SQS events, so that a single function can serve every trigger.
+func (h *AlarmHandler) Route(ctx context.Context, payload json.RawMessage) (any, error) {
+	scheduled := new(events.EventBridgeEvent)
+	if err := json.Unmarshal(payload, scheduled); err == nil &&
+		scheduled.Source == scheduledEventSource && scheduled.DetailType == scheduledEventDetailType {
+		detail := new(scheduledDetail)
+		if err := json.Unmarshal(scheduled.Detail, detail); err == nil && detail.Action == unmuteAction {
+			return h.Unmute(ctx, scheduled)
+		}
+		return h.Sweep(ctx, scheduled)
+	}
+
+	event := new(events.SQSEvent)
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal SQS event: %w", err)
+	}
+
+	return h.Handle(ctx, event)
+}
+
+// Handle processes every SQS record in the event, handling records for different resources concurrently.
+// Records that fail with a retryable error are returned as batch item failures so that only those messages are
+// redelivered. Records that fail permanently are logged and acknowledged.
 func (h *AlarmHandler) Handle(ctx context.Context, event *events.SQSEvent) (*events.SQSEventResponse, error) {
 	logger := log.Ctx(ctx).With().
 		Int("sqs_messages_count", len(event.Records)).
@@ -37,15 +95,101 @@ func (h *AlarmHandler) Handle(ctx context.Context, event *events.SQSEvent) (*eve
 
 	logger.Info().Msg("Received SQS event")
 
-	// do this for now, make better later
-	for _, record := range event.Records {
-		if err := h.handleSQSRecord(logger.WithContext(ctx), record); err != nil {
-			logger.Error().Str("sqs_message_id", record.MessageId).Err(err).Msg("Failed to process SQS record")
-			return nil, err
+	errs := h.processRecords(logger.WithContext(ctx), event.Records)
+
+	response := &events.SQSEventResponse{
+		BatchItemFailures: make([]events.SQSBatchItemFailure, 0),
+	}
+	for i, record := range event.Records {
+		err := errs[i]
+		if err == nil {
+			continue
+		}
+
+		if !isRetryable(err) {
+			logger.Error().Str("sqs_message_id", record.MessageId).Err(err).
+				Msg("Permanent failure processing SQS record, message will not be retried")
+			continue
+		}
+
+		logger.Error().Str("sqs_message_id", record.MessageId).Err(err).Msg("Failed to process SQS record")
+		response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+			ItemIdentifier: record.MessageId,
+		})
+	}
+
+	logger.Info().Int("sqs_failures_count", len(response.BatchItemFailures)).Msg("SQS event handling complete")
+
+	return response, nil
+}
+
+// processRecords handles the records with a bounded pool of workers and returns the error for each record by index.
+// Records for the same resource ARN are handled in order by a single worker, so that changes to one resource cannot
+// race. Once a record fails with a retryable error, the later records for its resource are not handled and are
+// returned as failed, so that the order is kept when they are redelivered.
+func (h *AlarmHandler) processRecords(ctx context.Context, records []events.SQSMessage) []error {
+	groups := make([][]int, 0)
+	byResource := make(map[string]int)
+	for i, record := range records {
+		key := recordResource(record)
+		if key == "" {
+			groups = append(groups, []int{i})
+			continue
 		}
+		if g, ok := byResource[key]; ok {
+			groups[g] = append(groups[g], i)
+			continue
+		}
+		byResource[key] = len(groups)
+		groups = append(groups, []int{i})
+	}
+
+	concurrency := h.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
 	}
 
-	return nil, nil
+	errs := make([]error, len(records))
+	work := make(chan []int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(groups)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range work {
+				var blocked error
+				for _, i := range group {
+					if blocked != nil {
+						errs[i] = fmt.Errorf("skipped after an earlier record for the same resource failed: %w", blocked)
+						continue
+					}
+					errs[i] = h.handleSQSRecord(ctx, records[i])
+					if errs[i] != nil && isRetryable(errs[i]) {
+						blocked = errs[i]
+					}
+				}
+			}
+		}()
+	}
+
+	for _, group := range groups {
+		work <- group
+	}
+	close(work)
+	wg.Wait()
+
+	return errs
+}
+
+// recordResource returns the first resource ARN of the EventBridge event in the record body, or an empty string if the
+// body cannot be read.
+func recordResource(record events.SQSMessage) string {
+	event := new(events.EventBridgeEvent)
+	if err := json.Unmarshal([]byte(record.Body), event); err != nil || len(event.Resources) == 0 {
+		return ""
+	}
+
+	return event.Resources[0]
 }
 
 func (h *AlarmHandler) handleSQSRecord(ctx context.Context, record events.SQSMessage) error {
@@ -56,7 +200,7 @@ func (h *AlarmHandler) handleSQSRecord(ctx context.Context, record events.SQSMes
 
 	event := new(events.EventBridgeEvent)
 	if err := json.Unmarshal([]byte(record.Body), event); err != nil {
-		return fmt.Errorf("failed to unmarshal event: %w", err)
+		return permanent(fmt.Errorf("failed to unmarshal event: %w", err))
 	}
 
 	logger = logger.With().Str("event_id", event.ID).Logger()
@@ -68,10 +212,10 @@ func (h *AlarmHandler) handleSQSRecord(ctx context.Context, record events.SQSMes
 		Strs("resources", event.Resources).
 		Msg("Received EventBridge event")
 	if err := filterEvent(event); err != nil {
-		return fmt.Errorf("unable to process event: %w", err)
+		return permanent(fmt.Errorf("unable to process event: %w", err))
 	}
 
-	return buildAndRun(logger.WithContext(ctx), h.MetricAPI, event)
+	return h.buildAndRun(logger.WithContext(ctx), event)
 }
 
 func filterEvent(event *events.EventBridgeEvent) error {
@@ -79,36 +223,46 @@ func filterEvent(event *events.EventBridgeEvent) error {
 		return fmt.Errorf("event source %s and detail-type %s does not match expected values", event.Source, event.DetailType)
 	}
 
+	if len(event.Resources) == 0 {
+		return fmt.Errorf("no resources in event")
+	}
+
 	resourceARN, err := arn.Parse(event.Resources[0])
 	if err != nil {
 		return fmt.Errorf("unable to parse resource ARN: %w", err)
 	}
 
-	if !slices.Contains([]string{"sqs"}, resourceARN.Service) {
+	if !slices.Contains(autoalarm.SupportedServices, resourceARN.Service) {
 		return fmt.Errorf("resource service %s is not supported", resourceARN.Service)
 	}
 
 	return nil
 }
 
-func buildAndRun(ctx context.Context, api autoalarm.MetricAlarmAPI, event *events.EventBridgeEvent) error {
+func (h *AlarmHandler) buildAndRun(ctx context.Context, event *events.EventBridgeEvent) error {
 	logger := log.Ctx(ctx)
-	config, err := NewConfig(ctx, event)
+	config, err := NewConfig(ctx, h.Defaults, event)
 	if err != nil {
-		return fmt.Errorf("unable to create config: %w", err)
+		return permanent(fmt.Errorf("unable to create config: %w", err))
 	}
 	logger.Info().Interface("config", config).Msg("Created config")
 
-	cmdRegistry := command.DefaultRegistry(api, logger)
+	cmdRegistry := command.DefaultRegistry(h.MetricAPI, logger, h.PutOptions...)
+	mapper := h.mapper(config)
 
-	cmdType := "cloudwatch"
-	if config.DryRun {
-		cmdType = "json"
-	}
+	cmdType := command.CommandType(config)
 
-	cmd, err := cmdRegistry.CreateCommand(ctx, cmdType, template.NewFileLoader(ctx, config))
+	var cmd autoalarm.Command
 	if config.Delete {
-		cmd, err = cmdRegistry.DeleteCommand(ctx, cmdType, template.NewFileFinder(ctx, config))
+		var finder command.AlarmNameFinder
+		finder, err = command.NewAlarmNameFinder(ctx, config, h.ResourceAPI, mapper, h.Templates)
+		if err != nil {
+			return permanent(fmt.Errorf("unable to create alarm finder: %w", err))
+		}
+		cmd, err = cmdRegistry.DeleteCommand(ctx, cmdType, finder)
+	} else {
+		cmd, err = cmdRegistry.CreateCommand(ctx, cmdType, template.NewFileLoader(ctx, config, mapper, h.Templates),
+			command.NewPruneFinder(config, h.ResourceAPI))
 	}
 	if err != nil {
 		return fmt.Errorf("unable to create command: %w", err)
@@ -121,3 +275,11 @@ func buildAndRun(ctx context.Context, api autoalarm.MetricAlarmAPI, event *event
 	logger.Info().Msg("event handling complete")
 	return nil
 }
+
+func (h *AlarmHandler) mapper(cfg *config.Config) *resources.Mapper {
+	return resources.NewMapper(cfg, &resources.Clients{
+		DescribeTableAPI: h.TableAPI,
+		GetFunctionAPI:   h.FunctionAPI,
+		QueueAPI:         h.QueueAPI,
+	})
+}
@@ -0,0 +1,310 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	cmdcw "github.com/akijowski/aws-auto-alarm/internal/command/cloudwatch"
+)
+
+type fakeMetricAlarmAPI struct {
+	mu           sync.Mutex
+	putErr       error
+	putInputs    []*cloudwatch.PutMetricAlarmInput
+	deleteInputs []*cloudwatch.DeleteAlarmsInput
+	// calls records "put" and "delete" calls with the alarm name, in the order they were made
+	calls    []string
+	inFlight int
+	maxPuts  int
+}
+
+func (f *fakeMetricAlarmAPI) PutMetricAlarm(_ context.Context, in *cloudwatch.PutMetricAlarmInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.maxPuts = max(f.maxPuts, f.inFlight)
+	f.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inFlight--
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+	f.putInputs = append(f.putInputs, in)
+	f.calls = append(f.calls, "put "+aws.ToString(in.AlarmName))
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) DeleteAlarms(_ context.Context, in *cloudwatch.DeleteAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DeleteAlarmsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleteInputs = append(f.deleteInputs, in)
+	for _, name := range in.AlarmNames {
+		f.calls = append(f.calls, "delete "+name)
+	}
+	return &cloudwatch.DeleteAlarmsOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) DescribeAlarms(_ context.Context, _ *cloudwatch.DescribeAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	return &cloudwatch.DescribeAlarmsOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) ListTagsForResource(_ context.Context, _ *cloudwatch.ListTagsForResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.ListTagsForResourceOutput, error) {
+	return &cloudwatch.ListTagsForResourceOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) TagResource(_ context.Context, _ *cloudwatch.TagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.TagResourceOutput, error) {
+	return &cloudwatch.TagResourceOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) UntagResource(_ context.Context, _ *cloudwatch.UntagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.UntagResourceOutput, error) {
+	return &cloudwatch.UntagResourceOutput{}, nil
+}
+
+// testPutOptions put alarms without a rate limit or retries, so that tests do not wait.
+var testPutOptions = []func(*cmdcw.CreateOptions){
+	func(o *cmdcw.CreateOptions) {
+		o.Limiter = rate.NewLimiter(rate.Inf, 1)
+		o.TagLimiter = rate.NewLimiter(rate.Inf, 1)
+		o.MaxAttempts = 1
+	},
+}
+
+func sqsRecord(t testing.TB, id string, resourceARN string) events.SQSMessage {
+	t.Helper()
+
+	return tagChangeRecord(t, id, resourceARN, map[string]string{
+		"AWS_AUTO_ALARM_ENABLED": "true",
+	})
+}
+
+// deleteRecord returns a record that removes AWS_AUTO_ALARM_ENABLED, deleting the alarms by their template names.
+func deleteRecord(t testing.TB, id string, resourceARN string) events.SQSMessage {
+	t.Helper()
+
+	return tagChangeRecord(t, id, resourceARN, map[string]string{
+		"AWS_AUTO_ALARM_DELETESTRATEGY": "template",
+	})
+}
+
+func tagChangeRecord(t testing.TB, id string, resourceARN string, tags map[string]string) events.SQSMessage {
+	t.Helper()
+
+	detail, err := json.Marshal(&tagChangeDetail{
+		ChangedTagKeys: []string{"AWS_AUTO_ALARM_ENABLED"},
+		Tags:           tags,
+	})
+	require.NoError(t, err)
+
+	body, err := json.Marshal(&events.EventBridgeEvent{
+		ID:         id,
+		Source:     eventbridgeEventSource,
+		DetailType: eventbridgeEventDetailType,
+		Resources:  []string{resourceARN},
+		Detail:     detail,
+	})
+	require.NoError(t, err)
+
+	return events.SQSMessage{MessageId: id, Body: string(body)}
+}
+
+func TestAlarmHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	queueARN := "arn:aws:sqs:us-east-1:123456789012:test-queue"
+
+	cases := map[string]struct {
+		putErr       error
+		records      func(t testing.TB) []events.SQSMessage
+		wantFailures []string
+		wantPuts     int
+	}{
+		"all records succeed": {
+			records: func(t testing.TB) []events.SQSMessage {
+				return []events.SQSMessage{
+					sqsRecord(t, "msg-1", queueARN),
+					sqsRecord(t, "msg-2", queueARN),
+				}
+			},
+			wantFailures: []string{},
+			wantPuts:     4,
+		},
+		"permanent failures are acknowledged": {
+			records: func(t testing.TB) []events.SQSMessage {
+				return []events.SQSMessage{
+					{MessageId: "malformed", Body: "not-json"},
+					sqsRecord(t, "unsupported", "arn:aws:ec2:us-east-1:123456789012:instance/i-0000000aaaaaaaaaa"),
+					sqsRecord(t, "msg-1", queueARN),
+				}
+			},
+			wantFailures: []string{},
+			wantPuts:     2,
+		},
+		"throttling failures are retried": {
+			putErr: &smithy.GenericAPIError{Code: "Throttling", Fault: smithy.FaultClient},
+			records: func(t testing.TB) []events.SQSMessage {
+				return []events.SQSMessage{
+					sqsRecord(t, "msg-1", queueARN),
+					{MessageId: "malformed", Body: "not-json"},
+					sqsRecord(t, "msg-2", queueARN),
+				}
+			},
+			wantFailures: []string{"msg-1", "msg-2"},
+		},
+		"network failures are retried": {
+			putErr: errors.New("connection reset by peer"),
+			records: func(t testing.TB) []events.SQSMessage {
+				return []events.SQSMessage{
+					sqsRecord(t, "msg-1", queueARN),
+				}
+			},
+			wantFailures: []string{"msg-1"},
+		},
+		"invalid template overrides are acknowledged": {
+			records: func(t testing.TB) []events.SQSMessage {
+				return []events.SQSMessage{
+					tagChangeRecord(t, "msg-1", queueARN, map[string]string{
+						"AWS_AUTO_ALARM_ENABLED":   "true",
+						"AWS_AUTO_ALARM_OVERRIDES": `{"sqs/missing": {"Threshold": 1000}}`,
+					}),
+				}
+			},
+			wantFailures: []string{},
+		},
+		"client validation failures are acknowledged": {
+			putErr: &smithy.GenericAPIError{Code: "ValidationError", Fault: smithy.FaultClient},
+			records: func(t testing.TB) []events.SQSMessage {
+				return []events.SQSMessage{
+					sqsRecord(t, "msg-1", queueARN),
+				}
+			},
+			wantFailures: []string{},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := zerolog.New(
+				zerolog.NewConsoleWriter(zerolog.ConsoleTestWriter(t)),
+			).With().Caller().Logger().WithContext(context.Background())
+
+			api := &fakeMetricAlarmAPI{putErr: tc.putErr}
+			handler := &AlarmHandler{MetricAPI: api, PutOptions: testPutOptions}
+
+			resp, err := handler.Handle(ctx, &events.SQSEvent{Records: tc.records(t)})
+			require.NoError(t, err)
+			require.NotNil(t, resp)
+
+			failures := make([]string, 0)
+			for _, f := range resp.BatchItemFailures {
+				failures = append(failures, f.ItemIdentifier)
+			}
+
+			assert.Equal(t, tc.wantFailures, failures)
+			assert.Len(t, api.putInputs, tc.wantPuts)
+		})
+	}
+}
+
+func TestAlarmHandler_Handle_concurrency(t *testing.T) {
+	t.Parallel()
+
+	queueARN := func(i int) string {
+		return fmt.Sprintf("arn:aws:sqs:us-east-1:123456789012:queue-%d", i)
+	}
+
+	t.Run("limits the number of records handled at once", func(t *testing.T) {
+		t.Parallel()
+
+		records := make([]events.SQSMessage, 0)
+		for i := range 8 {
+			records = append(records, sqsRecord(t, fmt.Sprintf("msg-%d", i), queueARN(i)))
+		}
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{
+			MetricAPI:   api,
+			Concurrency: 2,
+			PutOptions: append(testPutOptions, func(o *cmdcw.CreateOptions) {
+				o.Concurrency = 1
+			}),
+		}
+
+		resp, err := handler.Handle(context.TODO(), &events.SQSEvent{Records: records})
+		require.NoError(t, err)
+
+		assert.Empty(t, resp.BatchItemFailures)
+		assert.Len(t, api.putInputs, 16)
+		assert.LessOrEqual(t, api.maxPuts, 2)
+	})
+
+	t.Run("applies records for the same resource in order", func(t *testing.T) {
+		t.Parallel()
+
+		records := make([]events.SQSMessage, 0)
+		for i := range 4 {
+			records = append(records,
+				sqsRecord(t, fmt.Sprintf("enable-%d", i), queueARN(i)),
+				deleteRecord(t, fmt.Sprintf("disable-%d", i), queueARN(i)),
+			)
+		}
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{MetricAPI: api, Concurrency: 4, PutOptions: testPutOptions}
+
+		resp, err := handler.Handle(context.TODO(), &events.SQSEvent{Records: records})
+		require.NoError(t, err)
+		assert.Empty(t, resp.BatchItemFailures)
+
+		for i := range 4 {
+			queue := fmt.Sprintf("QueueName=queue-%d", i)
+			calls := make([]string, 0)
+			for _, call := range api.calls {
+				if strings.HasSuffix(call, queue) {
+					calls = append(calls, strings.Fields(call)[0])
+				}
+			}
+			assert.Equal(t, []string{"put", "delete", "delete"}, calls, queue)
+		}
+	})
+
+	t.Run("fails later records for a resource after a retryable failure", func(t *testing.T) {
+		t.Parallel()
+
+		api := &fakeMetricAlarmAPI{putErr: errors.New("connection reset by peer")}
+		handler := &AlarmHandler{MetricAPI: api, PutOptions: testPutOptions}
+
+		resp, err := handler.Handle(context.TODO(), &events.SQSEvent{Records: []events.SQSMessage{
+			sqsRecord(t, "enable", queueARN(0)),
+			deleteRecord(t, "disable", queueARN(0)),
+		}})
+		require.NoError(t, err)
+
+		failures := make([]string, 0)
+		for _, f := range resp.BatchItemFailures {
+			failures = append(failures, f.ItemIdentifier)
+		}
+		assert.Equal(t, []string{"enable", "disable"}, failures)
+		assert.Empty(t, api.deleteInputs)
+	})
+}
@@ -0,0 +1,263 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/command"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+	"github.com/akijowski/aws-auto-alarm/internal/template"
+)
+
+// SweepSummary counts the alarm changes made by a sweep.
+// Updated alarms are managed alarms that were upserted again, whether or not their definition changed.
+type SweepSummary struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+	Errored int `json:"errored"`
+}
+
+// Sweep reconciles the alarms of every resource in the account with its tags. It is run on an EventBridge schedule
+// to repair drift from tag change events that were missed or dropped.
+//
+// Every resource tagged AWS_AUTO_ALARM_ENABLED=true has its alarms upserted and its stale alarms pruned. Managed alarms
+// whose AWS_AUTO_ALARM_SOURCE_ARN is no longer an enabled resource are deleted. A failure for one resource is counted
+// as errored and does not stop the sweep.
+func (h *AlarmHandler) Sweep(ctx context.Context, event *events.EventBridgeEvent) (*SweepSummary, error) {
+	logger := log.Ctx(ctx).With().Str("event_id", event.ID).Logger()
+	ctx = logger.WithContext(ctx)
+	logger.Info().Msg("Starting alarm sweep")
+
+	if h.ResourceAPI == nil {
+		return nil, errors.New("a resources API is required to sweep alarms")
+	}
+
+	managed, err := autoalarm.ManagedAlarms(ctx, h.ResourceAPI)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find managed alarms: %w", err)
+	}
+
+	enabled, err := autoalarm.FindResources(ctx, h.ResourceAPI, map[string]string{"AWS_AUTO_ALARM_ENABLED": "true"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find enabled resources: %w", err)
+	}
+
+	summary := new(SweepSummary)
+	for _, mapping := range enabled {
+		resourceARN := aws.ToString(mapping.ResourceARN)
+		if err := h.sweepResource(ctx, resourceARN, mapping.Tags, managed[resourceARN], summary); err != nil {
+			logger.Error().Str("resource_arn", resourceARN).Err(err).Msg("Failed to sweep resource")
+			summary.Errored++
+		}
+		delete(managed, resourceARN)
+	}
+
+	// any managed alarms left belong to resources that are no longer enabled
+	orphans := make([]string, 0)
+	for _, names := range managed {
+		orphans = append(orphans, names...)
+	}
+	applied, err := h.deleteOrphans(ctx, orphans)
+	if err != nil {
+		logger.Error().Strs("alarm_names", orphans).Err(err).Msg("Failed to delete orphaned alarms")
+		summary.Errored++
+	} else if applied {
+		summary.Deleted += len(orphans)
+	}
+
+	summary.log(logger, "Alarm sweep complete")
+
+	return summary, nil
+}
+
+// Unmute puts the alarms of every resource with an alarm whose mute has ended again, which enables their actions and
+// removes their AWS_AUTO_ALARM_MUTED_UNTIL tag. It is run on a more frequent EventBridge schedule than Sweep, so that a
+// mute ends soon after its time, and it only reads the muted alarms when none have ended. Resources that are no longer
+// enabled are skipped, since their alarms are deleted by their tag change event or the next sweep.
+func (h *AlarmHandler) Unmute(ctx context.Context, event *events.EventBridgeEvent) (*SweepSummary, error) {
+	logger := log.Ctx(ctx).With().Str("event_id", event.ID).Logger()
+	ctx = logger.WithContext(ctx)
+
+	if h.ResourceAPI == nil {
+		return nil, errors.New("a resources API is required to unmute alarms")
+	}
+
+	expired, err := autoalarm.ExpiredMutes(ctx, h.ResourceAPI, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("unable to find muted alarms: %w", err)
+	}
+
+	summary := new(SweepSummary)
+	if len(expired) == 0 {
+		logger.Debug().Msg("No alarm mutes have ended")
+		return summary, nil
+	}
+
+	arns := make([]string, 0, len(expired))
+	for resourceARN := range expired {
+		arns = append(arns, resourceARN)
+	}
+	slices.Sort(arns)
+	logger.Info().Strs("resource_arns", arns).Msg("Unmuting the alarms of resources")
+
+	mappings, err := autoalarm.ResourcesByARN(ctx, h.ResourceAPI, arns)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find muted resources: %w", err)
+	}
+
+	for _, mapping := range mappings {
+		resourceARN := aws.ToString(mapping.ResourceARN)
+		if autoalarm.TagMap(mapping.Tags)["AWS_AUTO_ALARM_ENABLED"] != "true" {
+			logger.Debug().Str("resource_arn", resourceARN).Msg("Skipping muted resource that is no longer enabled")
+			continue
+		}
+
+		if err := h.unmuteResource(ctx, resourceARN, mapping.Tags, summary); err != nil {
+			logger.Error().Str("resource_arn", resourceARN).Err(err).Msg("Failed to unmute resource")
+			summary.Errored++
+		}
+	}
+
+	summary.log(logger, "Alarm unmute complete")
+
+	return summary, nil
+}
+
+// unmuteResource finds the managed alarms of the resource and then upserts and prunes them like sweepResource.
+func (h *AlarmHandler) unmuteResource(ctx context.Context, resourceARN string, tags []types.Tag, summary *SweepSummary) error {
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		return fmt.Errorf("unable to parse resource ARN: %w", err)
+	}
+
+	existing, err := autoalarm.NewNameFinder(h.ResourceAPI, parsed).Find(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to find alarms: %w", err)
+	}
+
+	return h.sweepResource(ctx, resourceARN, tags, existing, summary)
+}
+
+func (s *SweepSummary) log(logger zerolog.Logger, msg string) {
+	logger.Info().
+		Int("created", s.Created).
+		Int("updated", s.Updated).
+		Int("deleted", s.Deleted).
+		Int("errored", s.Errored).
+		Msg(msg)
+}
+
+// sweepResource upserts the alarms for a resource from its tags and prunes the existing managed alarms that were not
+// rendered. The summary is only updated for changes sent to CloudWatch.
+func (h *AlarmHandler) sweepResource(ctx context.Context, resourceARN string, tags []types.Tag, existing []string, summary *SweepSummary) error {
+	logger := log.Ctx(ctx).With().Str("resource_arn", resourceARN).Logger()
+	ctx = logger.WithContext(ctx)
+
+	cfg, err := newResourceConfig(ctx, h.Defaults, resourceARN, autoalarm.TagMap(tags))
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(autoalarm.SupportedServices, cfg.ParsedARN.Service) {
+		logger.Debug().Str("service", cfg.ParsedARN.Service).Msg("skipping unsupported resource service")
+		return nil
+	}
+
+	alarms, err := template.NewFileLoader(ctx, cfg, h.mapper(cfg), h.Templates).Load(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to load alarms: %w", err)
+	}
+
+	var pruner command.AlarmNameFinder
+	if !cfg.DisablePrune {
+		pruner = foundAlarms(existing)
+	}
+
+	cmdType := command.CommandType(cfg)
+	cmd, err := command.DefaultRegistry(h.MetricAPI, logger, h.PutOptions...).CreateCommand(ctx, cmdType, loadedAlarms(alarms), pruner)
+	if err != nil {
+		return fmt.Errorf("unable to create command: %w", err)
+	}
+
+	if err = cmd.Execute(ctx); err != nil {
+		return fmt.Errorf("unable to execute command: %w", err)
+	}
+
+	if cmdType != "cloudwatch" {
+		return nil
+	}
+
+	rendered := make(map[string]struct{}, len(alarms))
+	for _, alarm := range alarms {
+		name := aws.ToString(alarm.AlarmName)
+		rendered[name] = struct{}{}
+		if slices.Contains(existing, name) {
+			summary.Updated++
+		} else {
+			summary.Created++
+		}
+	}
+	if pruner != nil {
+		for _, name := range existing {
+			if _, ok := rendered[name]; !ok {
+				summary.Deleted++
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteOrphans deletes the named alarms with the command type of the AlarmHandler defaults, so a dry run or plan
+// sweep only reports them. It returns true when the alarms were deleted from CloudWatch.
+func (h *AlarmHandler) deleteOrphans(ctx context.Context, names []string) (bool, error) {
+	if len(names) == 0 {
+		return false, nil
+	}
+
+	cfg := h.Defaults
+	if cfg == nil {
+		cfg = new(config.Config)
+	}
+	cmdType := command.CommandType(cfg)
+
+	log.Ctx(ctx).Info().Strs("alarm_names", names).Str("command_type", cmdType).
+		Msg("deleting alarms for resources that are no longer enabled")
+	cmd, err := command.DefaultRegistry(h.MetricAPI, log.Ctx(ctx)).DeleteCommand(ctx, cmdType, foundAlarms(names))
+	if err != nil {
+		return false, err
+	}
+
+	if err = cmd.Execute(ctx); err != nil {
+		return false, err
+	}
+
+	return cmdType == "cloudwatch", nil
+}
+
+// loadedAlarms is a command.AlarmLoader for alarms that have already been rendered.
+type loadedAlarms []*cloudwatch.PutMetricAlarmInput
+
+func (l loadedAlarms) Load(_ context.Context) ([]*cloudwatch.PutMetricAlarmInput, error) {
+	return l, nil
+}
+
+// foundAlarms is a command.AlarmNameFinder for alarm names that have already been found.
+type foundAlarms []string
+
+func (f foundAlarms) Find(_ context.Context) ([]string, error) {
+	return f, nil
+}
@@ -0,0 +1,333 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+// fakeResourcesAPI returns alarms for requests filtered to cloudwatch:alarm and enabled resources otherwise.
+type fakeResourcesAPI struct {
+	alarms    []types.ResourceTagMapping
+	resources []types.ResourceTagMapping
+	err       error
+}
+
+func (f *fakeResourcesAPI) GetResources(_ context.Context, in *resourcegroupstaggingapi.GetResourcesInput, _ ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if slices.Contains(in.ResourceTypeFilters, "cloudwatch:alarm") {
+		return &resourcegroupstaggingapi.GetResourcesOutput{ResourceTagMappingList: f.alarms}, nil
+	}
+	return &resourcegroupstaggingapi.GetResourcesOutput{ResourceTagMappingList: f.resources}, nil
+}
+
+func managedAlarm(name, source string) types.ResourceTagMapping {
+	return types.ResourceTagMapping{
+		ResourceARN: aws.String("arn:aws:cloudwatch:us-east-1:123456789012:alarm:" + name),
+		Tags: []types.Tag{
+			{Key: aws.String("AWS_AUTO_ALARM_MANAGED"), Value: aws.String("true")},
+			{Key: aws.String("AWS_AUTO_ALARM_SOURCE_ARN"), Value: aws.String(source)},
+		},
+	}
+}
+
+func enabledResource(resourceARN string, tags map[string]string) types.ResourceTagMapping {
+	mapping := types.ResourceTagMapping{ResourceARN: aws.String(resourceARN)}
+	mapping.Tags = append(mapping.Tags, types.Tag{Key: aws.String("AWS_AUTO_ALARM_ENABLED"), Value: aws.String("true")})
+	for k, v := range tags {
+		mapping.Tags = append(mapping.Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return mapping
+}
+
+func TestAlarmHandler_Sweep(t *testing.T) {
+	t.Parallel()
+
+	queueA := "arn:aws:sqs:us-east-1:123456789012:queue-a"
+	queueB := "arn:aws:sqs:us-east-1:123456789012:queue-b"
+	queueC := "arn:aws:sqs:us-east-1:123456789012:queue-c"
+
+	t.Run("reconciles enabled resources and orphaned alarms", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := zerolog.New(zerolog.NewConsoleWriter(zerolog.ConsoleTestWriter(t))).WithContext(context.Background())
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{
+			MetricAPI:  api,
+			PutOptions: testPutOptions,
+			ResourceAPI: &fakeResourcesAPI{
+				alarms: []types.ResourceTagMapping{
+					managedAlarm("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-a", queueA),
+					managedAlarm("old queue-a alarm", queueA),
+					managedAlarm("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-c", queueC),
+				},
+				resources: []types.ResourceTagMapping{
+					enabledResource(queueA, nil),
+					enabledResource(queueB, nil),
+					enabledResource("arn:aws:sqs:us-east-1:123456789012:bad-overrides", map[string]string{
+						"AWS_AUTO_ALARM_OVERRIDES": "not-json",
+					}),
+					enabledResource("arn:aws:ec2:us-east-1:123456789012:instance/i-0000000aaaaaaaaaa", nil),
+				},
+			},
+		}
+
+		summary, err := handler.Sweep(ctx, &events.EventBridgeEvent{ID: "sweep"})
+		require.NoError(t, err)
+
+		assert.Equal(t, &SweepSummary{Created: 3, Updated: 1, Deleted: 2, Errored: 1}, summary)
+		assert.Len(t, api.putInputs, 4)
+
+		deleted := make([]string, 0)
+		for _, in := range api.deleteInputs {
+			deleted = append(deleted, in.AlarmNames...)
+		}
+		assert.ElementsMatch(t, []string{
+			"old queue-a alarm",
+			"AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-c",
+		}, deleted)
+	})
+
+	t.Run("removes the alarms of excluded templates", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := zerolog.New(zerolog.NewConsoleWriter(zerolog.ConsoleTestWriter(t))).WithContext(context.Background())
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{
+			MetricAPI:  api,
+			PutOptions: testPutOptions,
+			ResourceAPI: &fakeResourcesAPI{
+				alarms: []types.ResourceTagMapping{
+					managedAlarm("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-a", queueA),
+					managedAlarm("AWS/SQS DLQ ApproximateNumberOfMessagesVisible > 0 QueueName=queue-a-dlq", queueA),
+				},
+				resources: []types.ResourceTagMapping{
+					enabledResource(queueA, map[string]string{"AWS_AUTO_ALARM_EXCLUDE": "sqs/dlq-messages-visible"}),
+				},
+			},
+		}
+
+		summary, err := handler.Sweep(ctx, &events.EventBridgeEvent{ID: "sweep"})
+		require.NoError(t, err)
+
+		assert.Equal(t, &SweepSummary{Updated: 1, Deleted: 1}, summary)
+		require.Len(t, api.putInputs, 1)
+		assert.Equal(t, "AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-a", aws.ToString(api.putInputs[0].AlarmName))
+		require.Len(t, api.deleteInputs, 1)
+		assert.Equal(t, []string{"AWS/SQS DLQ ApproximateNumberOfMessagesVisible > 0 QueueName=queue-a-dlq"}, api.deleteInputs[0].AlarmNames)
+	})
+
+	t.Run("dry runs do not change or delete alarms", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := zerolog.New(zerolog.NewConsoleWriter(zerolog.ConsoleTestWriter(t))).WithContext(context.Background())
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{
+			MetricAPI:  api,
+			PutOptions: testPutOptions,
+			Defaults:   &config.Config{DryRun: true},
+			ResourceAPI: &fakeResourcesAPI{
+				alarms: []types.ResourceTagMapping{
+					managedAlarm("old queue-a alarm", queueA),
+					managedAlarm("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-c", queueC),
+				},
+				resources: []types.ResourceTagMapping{
+					enabledResource(queueA, nil),
+				},
+			},
+		}
+
+		summary, err := handler.Sweep(ctx, &events.EventBridgeEvent{ID: "sweep"})
+		require.NoError(t, err)
+
+		assert.Equal(t, &SweepSummary{}, summary)
+		assert.Empty(t, api.putInputs)
+		assert.Empty(t, api.deleteInputs)
+	})
+
+	t.Run("mutes alarm actions until the mute expires", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := zerolog.New(zerolog.NewConsoleWriter(zerolog.ConsoleTestWriter(t))).WithContext(context.Background())
+
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{
+			MetricAPI:  api,
+			PutOptions: testPutOptions,
+			ResourceAPI: &fakeResourcesAPI{
+				resources: []types.ResourceTagMapping{
+					enabledResource(queueA, map[string]string{"AWS_AUTO_ALARM_MUTE_UNTIL": future}),
+					enabledResource(queueB, map[string]string{"AWS_AUTO_ALARM_MUTE_UNTIL": past}),
+				},
+			},
+		}
+
+		_, err := handler.Sweep(ctx, &events.EventBridgeEvent{ID: "sweep"})
+		require.NoError(t, err)
+		require.NotEmpty(t, api.putInputs)
+
+		for _, in := range api.putInputs {
+			muted := strings.HasSuffix(aws.ToString(in.AlarmName), "queue-a") || strings.HasSuffix(aws.ToString(in.AlarmName), "queue-a-dlq")
+			assert.Equal(t, !muted, aws.ToBool(in.ActionsEnabled), aws.ToString(in.AlarmName))
+			if muted {
+				assert.Contains(t, in.Tags, cwtypes.Tag{Key: aws.String("AWS_AUTO_ALARM_MUTED_UNTIL"), Value: aws.String(future)})
+			} else {
+				assert.NotContains(t, in.Tags, cwtypes.Tag{Key: aws.String("AWS_AUTO_ALARM_MUTED_UNTIL"), Value: aws.String(past)})
+			}
+		}
+	})
+
+	t.Run("returns errors finding resources", func(t *testing.T) {
+		t.Parallel()
+
+		handler := &AlarmHandler{
+			MetricAPI:   &fakeMetricAlarmAPI{},
+			ResourceAPI: &fakeResourcesAPI{err: errors.New("boom")},
+		}
+
+		_, err := handler.Sweep(context.TODO(), &events.EventBridgeEvent{ID: "sweep"})
+		assert.Error(t, err)
+	})
+}
+
+func TestAlarmHandler_Unmute(t *testing.T) {
+	t.Parallel()
+
+	queueA := "arn:aws:sqs:us-east-1:123456789012:queue-a"
+	queueB := "arn:aws:sqs:us-east-1:123456789012:queue-b"
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	mutedAlarm := func(name, source, until string) types.ResourceTagMapping {
+		mapping := managedAlarm(name, source)
+		mapping.Tags = append(mapping.Tags, types.Tag{Key: aws.String("AWS_AUTO_ALARM_MUTED_UNTIL"), Value: aws.String(until)})
+		return mapping
+	}
+
+	t.Run("puts the alarms of resources whose mute has ended", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := zerolog.New(zerolog.NewConsoleWriter(zerolog.ConsoleTestWriter(t))).WithContext(context.Background())
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{
+			MetricAPI:  api,
+			PutOptions: testPutOptions,
+			ResourceAPI: &fakeResourcesAPI{
+				alarms: []types.ResourceTagMapping{
+					mutedAlarm("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-b", queueB, past),
+				},
+				resources: []types.ResourceTagMapping{
+					enabledResource(queueB, map[string]string{"AWS_AUTO_ALARM_MUTE_UNTIL": past}),
+				},
+			},
+		}
+
+		summary, err := handler.Unmute(ctx, &events.EventBridgeEvent{ID: "unmute"})
+		require.NoError(t, err)
+
+		// the queue and its dead-letter queue are alarmed, and only the queue alarm existed
+		assert.Equal(t, &SweepSummary{Created: 1, Updated: 1}, summary)
+		require.Len(t, api.putInputs, 2)
+		for _, in := range api.putInputs {
+			assert.True(t, aws.ToBool(in.ActionsEnabled), aws.ToString(in.AlarmName))
+			assert.NotContains(t, in.Tags, cwtypes.Tag{Key: aws.String("AWS_AUTO_ALARM_MUTED_UNTIL"), Value: aws.String(past)})
+		}
+	})
+
+	t.Run("does nothing while alarms are muted", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := zerolog.New(zerolog.NewConsoleWriter(zerolog.ConsoleTestWriter(t))).WithContext(context.Background())
+
+		api := &fakeMetricAlarmAPI{}
+		handler := &AlarmHandler{
+			MetricAPI:  api,
+			PutOptions: testPutOptions,
+			ResourceAPI: &fakeResourcesAPI{
+				alarms: []types.ResourceTagMapping{
+					mutedAlarm("AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=queue-a", queueA, future),
+				},
+				resources: []types.ResourceTagMapping{
+					enabledResource(queueA, map[string]string{"AWS_AUTO_ALARM_MUTE_UNTIL": future}),
+				},
+			},
+		}
+
+		summary, err := handler.Unmute(ctx, &events.EventBridgeEvent{ID: "unmute"})
+		require.NoError(t, err)
+
+		assert.Equal(t, &SweepSummary{}, summary)
+		assert.Empty(t, api.putInputs)
+	})
+}
+
+func TestAlarmHandler_Route(t *testing.T) {
+	t.Parallel()
+
+	handler := &AlarmHandler{
+		MetricAPI:   &fakeMetricAlarmAPI{},
+		ResourceAPI: &fakeResourcesAPI{},
+	}
+
+	t.Run("scheduled events are swept", func(t *testing.T) {
+		t.Parallel()
+
+		payload, err := json.Marshal(&events.EventBridgeEvent{
+			Source:     scheduledEventSource,
+			DetailType: scheduledEventDetailType,
+			Detail:     json.RawMessage("{}"),
+		})
+		require.NoError(t, err)
+
+		out, err := handler.Route(context.TODO(), payload)
+		require.NoError(t, err)
+		assert.IsType(t, &SweepSummary{}, out)
+	})
+
+	t.Run("scheduled unmute events are unmuted", func(t *testing.T) {
+		t.Parallel()
+
+		payload, err := json.Marshal(&events.EventBridgeEvent{
+			Source:     scheduledEventSource,
+			DetailType: scheduledEventDetailType,
+			Detail:     json.RawMessage(`{"action":"unmute"}`),
+		})
+		require.NoError(t, err)
+
+		out, err := handler.Route(context.TODO(), payload)
+		require.NoError(t, err)
+		assert.IsType(t, &SweepSummary{}, out)
+	})
+
+	t.Run("SQS events are handled", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := handler.Route(context.TODO(), json.RawMessage(`{"Records":[]}`))
+		require.NoError(t, err)
+		assert.IsType(t, &events.SQSEventResponse{}, out)
+	})
+}
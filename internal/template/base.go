@@ -1,6 +1,8 @@
 package template
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
@@ -9,6 +11,8 @@ import (
 )
 
 // alarmBase returns a cloudwatch.PutMetricAlarmInput that will be applied to all generated alarms.
+// The actions of the config.Config must already have their routing aliases resolved. While the config.Config is muted,
+// the alarm actions are disabled and the alarms are tagged with AWS_AUTO_ALARM_MUTED_UNTIL.
 func alarmBase(cfg *config.Config) *cloudwatch.PutMetricAlarmInput {
 	base := &cloudwatch.PutMetricAlarmInput{
 		ActionsEnabled: aws.Bool(true),
@@ -23,6 +27,15 @@ func alarmBase(cfg *config.Config) *cloudwatch.PutMetricAlarmInput {
 	if cfg != nil {
 		base.OKActions = cfg.OKActions
 		base.AlarmActions = cfg.AlarmActions
+		base.InsufficientDataActions = cfg.InsufficientDataActions
+
+		if cfg.Muted(time.Now()) {
+			base.ActionsEnabled = aws.Bool(false)
+			base.Tags = append(base.Tags, types.Tag{
+				Key:   aws.String("AWS_AUTO_ALARM_MUTED_UNTIL"),
+				Value: aws.String(cfg.MuteUntil.UTC().Format(time.RFC3339)),
+			})
+		}
 	}
 
 	return base
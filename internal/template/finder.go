@@ -8,41 +8,62 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 
 	"github.com/akijowski/aws-auto-alarm/internal/config"
-	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
 )
 
-// FileFinder returns a slice of alarm names by applying the autoalarm.Config to the provided templates in the
-// embedded fs.FS.
+// FileFinder returns a slice of alarm names by applying the autoalarm.Config to the provided templates in an fs.FS.
+// The embedded templates are used for any service that the fs.FS does not have templates for.
 type FileFinder struct {
-	config       *config.Config
-	baseAlarm    *cloudwatch.PutMetricAlarmInput
-	fs           fs.FS
-	templateData *alarmData
+	config    *config.Config
+	baseAlarm *cloudwatch.PutMetricAlarmInput
+	sources   []fs.FS
+	mapper    ResourceMapper
 }
 
-func NewFileFinder(ctx context.Context, cfg *config.Config) *FileFinder {
-	data := newAlarmData(ctx, cfg, resources.NewMapper(cfg))
+// NewFileFinder returns a FileFinder for the templates in src. A nil src uses only the embedded templates.
+func NewFileFinder(_ context.Context, cfg *config.Config, m ResourceMapper, src fs.FS) *FileFinder {
 	return &FileFinder{
-		config:       cfg,
-		baseAlarm:    alarmBase(cfg),
-		templateData: data,
-		fs:           content,
+		config:    cfg,
+		baseAlarm: alarmBase(cfg),
+		sources:   templateSources(src),
+		mapper:    m,
 	}
 }
 
-func (f *FileFinder) Find(_ context.Context) ([]string, error) {
-	tmpls, err := templates(f.fs, f.config.ParsedARN)
+// Find renders every template for the resource and returns the alarm names. The include and exclude lists of the
+// config.Config are ignored, so alarms are found even if their template was excluded after they were created, but
+// overrides are applied because they can change the names.
+func (f *FileFinder) Find(ctx context.Context) ([]string, error) {
+	data, err := newAlarmData(ctx, f.config, f.mapper)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := newDeleteOptions(f.sources, f.config)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpls, err := templates(f.sources, f.config.ParsedARN)
 	if err != nil {
 		return nil, err
 	}
 
 	names := make([]string, 0)
 	for _, tmpl := range tmpls {
-		alarm, err := newAlarm(tmpl, f.templateData, f.baseAlarm)
+		id := templateID(f.config.ParsedARN.Service, tmpl.Name())
+		if !opts.selected(id) {
+			continue
+		}
+		alarms, err := newAlarms(tmpl, data, f.baseAlarm, f.config.SeverityActions)
 		if err != nil {
 			return nil, err
 		}
-		names = append(names, aws.ToString(alarm.AlarmName))
+		if err = opts.apply(id, alarms); err != nil {
+			return nil, err
+		}
+		for _, alarm := range alarms {
+			names = append(names, aws.ToString(alarm.AlarmName))
+		}
 	}
 
 	return names, nil
@@ -0,0 +1,33 @@
+package template
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// templateFuncs are the functions that the alarm templates can call, in addition to the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"queryID": queryID,
+}
+
+// queryID returns the metric math query ID for a metric or expression name, such as errors for Errors and
+// numberOfMessagesDeleted for NumberOfMessagesDeleted, so a template can refer to its queries by name.
+// CloudWatch query IDs must start with a lowercase letter and have only letters, numbers and underscores: the first
+// letter is lowercased, other characters are replaced with an underscore and a name that does not start with a
+// letter is prefixed with m.
+func queryID(name string) string {
+	id := []rune(strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, name))
+
+	if len(id) == 0 || !unicode.IsLetter(id[0]) {
+		return "m" + string(id)
+	}
+	id[0] = unicode.ToLower(id[0])
+
+	return string(id)
+}
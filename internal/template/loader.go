@@ -11,33 +11,38 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/akijowski/aws-auto-alarm/internal/config"
-	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
 )
 
-// FileLoader loads alarm input based on the embedded fs.FS of templates and a base alarm generated with the provided
-// config.Config.
+// FileLoader loads alarm input based on an fs.FS of templates and a base alarm generated with the provided
+// config.Config. The embedded templates are used for any service that the fs.FS does not have templates for.
 type FileLoader struct {
-	config       *config.Config
-	baseAlarm    *cloudwatch.PutMetricAlarmInput
-	fs           fs.FS
-	templateData *alarmData
+	config    *config.Config
+	baseAlarm *cloudwatch.PutMetricAlarmInput
+	sources   []fs.FS
+	mapper    ResourceMapper
 }
 
-func NewFileLoader(ctx context.Context, cfg *config.Config) *FileLoader {
+// NewFileLoader returns a FileLoader for the templates in src. A nil src uses only the embedded templates.
+func NewFileLoader(ctx context.Context, cfg *config.Config, m ResourceMapper, src fs.FS) *FileLoader {
 	logger := log.Ctx(ctx)
 	logger.Debug().Msg("creating new file loader")
-	data := newAlarmData(ctx, cfg, resources.NewMapper(cfg))
-	logger.Debug().Interface("alarm_data", data).Msg("alarm data created")
 	return &FileLoader{
-		config:       cfg,
-		baseAlarm:    alarmBase(cfg),
-		templateData: data,
-		fs:           content,
+		config:    cfg,
+		baseAlarm: alarmBase(cfg),
+		sources:   templateSources(src),
+		mapper:    m,
 	}
 }
 
-func templates(content fs.FS, arn awsarn.ARN) ([]*template.Template, error) {
-	tmpls, err := template.ParseFS(content, fmt.Sprintf("templates/%s/*", arn.Service))
+// templates parses the templates for the service of the ARN from the first source that has any. The templates can
+// call the templateFuncs.
+func templates(sources []fs.FS, arn awsarn.ARN) ([]*template.Template, error) {
+	src, pattern, err := serviceSource(sources, arn.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpls, err := template.New(arn.Service).Funcs(templateFuncs).ParseFS(src, pattern)
 	if err != nil {
 		return nil, fmt.Errorf("template parse error: %w", err)
 	}
@@ -45,23 +50,59 @@ func templates(content fs.FS, arn awsarn.ARN) ([]*template.Template, error) {
 	return tmpls.Templates(), nil
 }
 
+// serviceSource returns the first source that has templates for the service, and the pattern that matches them.
+func serviceSource(sources []fs.FS, service string) (fs.FS, string, error) {
+	pattern := fmt.Sprintf("%s/*", service)
+	for _, src := range sources {
+		matches, err := fs.Glob(src, pattern)
+		if err != nil {
+			return nil, "", fmt.Errorf("template glob error: %w", err)
+		}
+		if len(matches) > 0 {
+			return src, pattern, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("no templates found for service %s", service)
+}
+
 // Load parses template.Template from the local file system using the configured config.Config, base Alarm, and alarmData.
 func (f *FileLoader) Load(ctx context.Context) ([]*cloudwatch.PutMetricAlarmInput, error) {
 	logger := log.Ctx(ctx)
 	logger.Debug().Msg("loading from file templates")
-	tmpls, err := templates(f.fs, f.config.ParsedARN)
+	opts, err := newTemplateOptions(f.sources, f.config)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := newAlarmData(ctx, f.config, f.mapper)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create alarm data: %w", err)
+	}
+	logger.Debug().Interface("alarm_data", data).Msg("alarm data created")
+
+	tmpls, err := templates(f.sources, f.config.ParsedARN)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get templates: %w", err)
 	}
 
-	logger.Debug().Int("alarms_count", len(tmpls)).Msg("templates loaded")
+	logger.Debug().Int("templates_count", len(tmpls)).Msg("templates loaded")
 	alarms := make([]*cloudwatch.PutMetricAlarmInput, 0)
 	for _, tmpl := range tmpls {
-		alarm, err := newAlarm(tmpl, f.templateData, f.baseAlarm)
+		id := templateID(f.config.ParsedARN.Service, tmpl.Name())
+		if !opts.selected(id) {
+			logger.Debug().Str("template", id).Msg("template not selected")
+			continue
+		}
+		data.overrides = opts.overrides[id]
+		tmplAlarms, err := newAlarms(tmpl, data, f.baseAlarm, f.config.SeverityActions)
 		if err != nil {
 			return nil, fmt.Errorf("unable to create alarm from template: %w", err)
 		}
-		alarms = append(alarms, alarm)
+		if err = opts.apply(id, tmplAlarms); err != nil {
+			return nil, err
+		}
+		alarms = append(alarms, tmplAlarms...)
 	}
 
 	return alarms, nil
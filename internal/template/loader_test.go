@@ -0,0 +1,496 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+	"github.com/akijowski/aws-auto-alarm/internal/template/resources"
+)
+
+type stubMapper map[string]any
+
+func (m stubMapper) Map(_ context.Context) (map[string]any, error) {
+	return m, nil
+}
+
+func TestFileLoader_Load(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		ParsedARN: arn.ARN{
+			Partition: "aws",
+			Service:   "dynamodb",
+			Region:    "us-east-1",
+			AccountID: "123456789012",
+			Resource:  "table/my-table",
+		},
+	}
+
+	t.Run("renders an alarm per global secondary index", func(t *testing.T) {
+		t.Parallel()
+
+		mapper := stubMapper{
+			"TableName":              "my-table",
+			"ReadCapacityThreshold":  float64(480),
+			"WriteCapacityThreshold": float64(0),
+			"GlobalSecondaryIndexes": []resources.GlobalSecondaryIndex{
+				{IndexName: "by-owner", ReadCapacityThreshold: 960, WriteCapacityThreshold: 480},
+				{IndexName: "by-date"},
+			},
+		}
+
+		alarms, err := NewFileLoader(context.TODO(), cfg, mapper, nil).Load(context.TODO())
+		require.NoError(t, err)
+
+		names := make([]string, 0)
+		for _, alarm := range alarms {
+			names = append(names, aws.ToString(alarm.AlarmName))
+		}
+
+		assert.ElementsMatch(t, []string{
+			"AWS/DynamoDB ReadThrottleEvents > 0 TableName=my-table",
+			"AWS/DynamoDB WriteThrottleEvents > 0 TableName=my-table",
+			"AWS/DynamoDB SystemErrors > 0 TableName=my-table",
+			"AWS/DynamoDB ConsumedReadCapacityUnits > 80% provisioned TableName=my-table",
+			"AWS/DynamoDB ReadThrottleEvents > 0 TableName=my-table GlobalSecondaryIndexName=by-owner",
+			"AWS/DynamoDB ReadThrottleEvents > 0 TableName=my-table GlobalSecondaryIndexName=by-date",
+			"AWS/DynamoDB WriteThrottleEvents > 0 TableName=my-table GlobalSecondaryIndexName=by-owner",
+			"AWS/DynamoDB WriteThrottleEvents > 0 TableName=my-table GlobalSecondaryIndexName=by-date",
+			"AWS/DynamoDB ConsumedReadCapacityUnits > 80% provisioned TableName=my-table GlobalSecondaryIndexName=by-owner",
+			"AWS/DynamoDB ConsumedWriteCapacityUnits > 80% provisioned TableName=my-table GlobalSecondaryIndexName=by-owner",
+		}, names)
+
+		for _, alarm := range alarms {
+			if aws.ToString(alarm.AlarmName) == "AWS/DynamoDB ConsumedReadCapacityUnits > 80% provisioned TableName=my-table GlobalSecondaryIndexName=by-owner" {
+				assert.Equal(t, float64(960), aws.ToFloat64(alarm.Threshold))
+				assert.Len(t, alarm.Dimensions, 2)
+			}
+		}
+	})
+
+	t.Run("renders no index alarms without indexes", func(t *testing.T) {
+		t.Parallel()
+
+		mapper := stubMapper{
+			"TableName":              "my-table",
+			"ReadCapacityThreshold":  float64(0),
+			"WriteCapacityThreshold": float64(0),
+			"GlobalSecondaryIndexes": []resources.GlobalSecondaryIndex{},
+		}
+
+		alarms, err := NewFileLoader(context.TODO(), cfg, mapper, nil).Load(context.TODO())
+		require.NoError(t, err)
+
+		assert.Len(t, alarms, 3)
+	})
+}
+
+func TestFileLoader_Load_severity(t *testing.T) {
+	t.Parallel()
+
+	src := fstest.MapFS{
+		"sqs/critical.json.tmpl": {Data: []byte(`{"AlarmName": "critical", "Severity": "critical"}`)},
+		"sqs/warning.json.tmpl":  {Data: []byte(`{"AlarmName": "warning", "Severity": "warning"}`)},
+		"sqs/none.json.tmpl":     {Data: []byte(`{"AlarmName": "none"}`)},
+	}
+
+	cfg := sqsConfig()
+	cfg.AlarmActions = []string{"arn:aws:sns:us-east-1:123456789012:default"}
+	cfg.OKActions = []string{"arn:aws:sns:us-east-1:123456789012:default-ok"}
+	cfg.SeverityActions = map[string]config.Route{
+		config.SeverityCritical: {AlarmActions: []string{"arn:aws:sns:us-east-1:123456789012:pager"}},
+	}
+
+	alarms, err := NewFileLoader(context.TODO(), cfg, stubMapper{}, src).Load(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, alarms, 3)
+
+	byName := make(map[string]*cloudwatch.PutMetricAlarmInput)
+	for _, alarm := range alarms {
+		byName[aws.ToString(alarm.AlarmName)] = alarm
+	}
+
+	assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:pager"}, byName["critical"].AlarmActions)
+	assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:default-ok"}, byName["critical"].OKActions)
+	assert.Contains(t, byName["critical"].Tags, types.Tag{Key: aws.String("AWS_AUTO_ALARM_SEVERITY"), Value: aws.String("critical")})
+
+	assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:default"}, byName["warning"].AlarmActions)
+	assert.Contains(t, byName["warning"].Tags, types.Tag{Key: aws.String("AWS_AUTO_ALARM_SEVERITY"), Value: aws.String("warning")})
+
+	assert.Equal(t, []string{"arn:aws:sns:us-east-1:123456789012:default"}, byName["none"].AlarmActions)
+	assert.Len(t, byName["none"].Tags, 2)
+
+	t.Run("unknown severities return an error", func(t *testing.T) {
+		t.Parallel()
+
+		src := fstest.MapFS{
+			"sqs/urgent.json.tmpl": {Data: []byte(`{"AlarmName": "urgent", "Severity": "urgent"}`)},
+		}
+
+		_, err := NewFileLoader(context.TODO(), sqsConfig(), stubMapper{}, src).Load(context.TODO())
+		assert.ErrorContains(t, err, `unknown severity "urgent"`)
+	})
+}
+
+func TestFileLoader_Load_overrides(t *testing.T) {
+	t.Parallel()
+
+	mapper := stubMapper{"QueueName": "my-queue"}
+
+	t.Run("overrides are applied to the alarms of the template", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := sqsConfig()
+		cfg.Overrides = map[string]any{
+			"SQS_DLQ_NAME":         "my-queue-dlq",
+			"sqs/messages-visible": map[string]any{"Threshold": float64(1000), "EvaluationPeriods": 5},
+		}
+
+		alarms, err := NewFileLoader(context.TODO(), cfg, mapper, nil).Load(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, alarms, 1)
+
+		assert.Equal(t, "AWS/SQS ApproximateNumberOfMessagesVisible > 1000 QueueName=my-queue", aws.ToString(alarms[0].AlarmName))
+		assert.Equal(t, float64(1000), aws.ToFloat64(alarms[0].Threshold))
+		assert.Equal(t, int32(5), aws.ToInt32(alarms[0].EvaluationPeriods))
+		assert.Equal(t, int32(15), aws.ToInt32(alarms[0].DatapointsToAlarm))
+	})
+
+	t.Run("invalid overrides return a ConfigError", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := sqsConfig()
+		cfg.Overrides = map[string]any{
+			"sqs/messages-visible": map[string]any{"Threshold": "high", "Thresold": 5},
+			"sqs/missing":          map[string]any{"Threshold": 5},
+			"lambda/errors":        1000,
+			"SQS_DLQ_NAME":         float64(1),
+		}
+
+		_, err := NewFileLoader(context.TODO(), cfg, mapper, nil).Load(context.TODO())
+
+		var cErr *ConfigError
+		require.ErrorAs(t, err, &cErr)
+		assert.Equal(t, []string{
+			"override SQS_DLQ_NAME must be a string, not 1",
+			"override lambda/errors must be an object of alarm fields",
+			"override sqs/messages-visible.Threshold: invalid value high",
+			"override sqs/messages-visible.Thresold: unknown alarm field",
+			"override sqs/missing does not match a template",
+		}, cErr.Problems)
+	})
+}
+
+func TestFileLoader_Load_selection(t *testing.T) {
+	t.Parallel()
+
+	mapper := stubMapper{"QueueName": "my-queue", "DLQName": "my-queue-dlq"}
+
+	cases := map[string]struct {
+		include []string
+		exclude []string
+		want    []string
+		wantErr []string
+	}{
+		"all templates without lists": {
+			want: []string{"sqs/messages-visible", "sqs/dlq-messages-visible"},
+		},
+		"include by template name": {
+			include: []string{"dlq-messages-visible"},
+			want:    []string{"sqs/dlq-messages-visible"},
+		},
+		"exclude by template ID": {
+			exclude: []string{"sqs/dlq-messages-visible"},
+			want:    []string{"sqs/messages-visible"},
+		},
+		"exclude wins over include": {
+			include: []string{"messages-visible", "dlq-messages-visible"},
+			exclude: []string{"messages-visible"},
+			want:    []string{"sqs/dlq-messages-visible"},
+		},
+		"templates of other services are known": {
+			exclude: []string{"lambda/errors"},
+			want:    []string{"sqs/messages-visible", "sqs/dlq-messages-visible"},
+		},
+		"opt-in templates are rendered when included": {
+			include: []string{"messages-visible", "delete-ratio"},
+			want:    []string{"sqs/messages-visible", "sqs/delete-ratio"},
+		},
+		"unknown templates return a ConfigError": {
+			include: []string{"sqs/missing"},
+			exclude: []string{"missing"},
+			wantErr: []string{
+				"exclude missing does not match a template",
+				"include sqs/missing does not match a template",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := sqsConfig()
+			cfg.Include = tc.include
+			cfg.Exclude = tc.exclude
+
+			alarms, err := NewFileLoader(context.TODO(), cfg, mapper, nil).Load(context.TODO())
+			names, findErr := NewFileFinder(context.TODO(), cfg, mapper, nil).Find(context.TODO())
+
+			// the finder ignores the lists, so that deletes find the alarms of every template
+			require.NoError(t, findErr)
+			assert.Len(t, names, 3)
+
+			if tc.wantErr != nil {
+				var cErr *ConfigError
+				require.ErrorAs(t, err, &cErr)
+				assert.Equal(t, tc.wantErr, cErr.Problems)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, alarms, len(tc.want))
+		})
+	}
+}
+
+func TestFileLoader_Load_limits(t *testing.T) {
+	t.Parallel()
+
+	src := fstest.MapFS{
+		"sqs/long.json.tmpl": {Data: []byte(`{
+			"AlarmName": "AWS/SQS ApproximateNumberOfMessagesVisible > 0 QueueName={{ .Resources.QueueName }}",
+			"AlarmDescription": "{{ .Resources.QueueName }}{{ .Resources.QueueName }}{{ .Resources.QueueName }}{{ .Resources.QueueName }}{{ .Resources.QueueName }}"
+		}`)},
+	}
+
+	t.Run("long names and descriptions are shortened with a hash", func(t *testing.T) {
+		t.Parallel()
+
+		queue := strings.Repeat("q", 250)
+		other := strings.Repeat("q", 249) + "r"
+
+		alarms, err := NewFileLoader(context.TODO(), sqsConfig(), stubMapper{"QueueName": queue}, src).Load(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, alarms, 1)
+		names, err := NewFileFinder(context.TODO(), sqsConfig(), stubMapper{"QueueName": queue}, src).Find(context.TODO())
+		require.NoError(t, err)
+		others, err := NewFileFinder(context.TODO(), sqsConfig(), stubMapper{"QueueName": other}, src).Find(context.TODO())
+		require.NoError(t, err)
+
+		name := aws.ToString(alarms[0].AlarmName)
+		assert.Len(t, name, MaxAlarmNameLength)
+		assert.True(t, strings.HasPrefix(name, "AWS/SQS ApproximateNumberOfMessagesVisible > 0 QueueName=qqq"))
+		assert.Equal(t, []string{name}, names)
+		assert.NotEqual(t, names, others)
+		assert.Len(t, aws.ToString(alarms[0].AlarmDescription), MaxAlarmDescriptionLength)
+	})
+
+	t.Run("short names are unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		alarms, err := NewFileLoader(context.TODO(), sqsConfig(), stubMapper{"QueueName": "my-queue"}, src).Load(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, alarms, 1)
+
+		assert.Equal(t, "AWS/SQS ApproximateNumberOfMessagesVisible > 0 QueueName=my-queue", aws.ToString(alarms[0].AlarmName))
+		assert.Equal(t, strings.Repeat("my-queue", 5), aws.ToString(alarms[0].AlarmDescription))
+	})
+
+	t.Run("names with control characters return an error", func(t *testing.T) {
+		t.Parallel()
+
+		src := fstest.MapFS{
+			"sqs/bell.json.tmpl": {Data: []byte(`{"AlarmName": "bell \u0007 {{ .Resources.QueueName }}"}`)},
+		}
+
+		_, err := NewFileLoader(context.TODO(), sqsConfig(), stubMapper{"QueueName": "my-queue"}, src).Load(context.TODO())
+		assert.ErrorContains(t, err, "name has control characters")
+	})
+}
+
+func TestFileLoader_Load_propagateTags(t *testing.T) {
+	t.Parallel()
+
+	src := fstest.MapFS{
+		"sqs/alarm.json.tmpl": {Data: []byte(`{"AlarmName": "alarm"}`)},
+	}
+
+	t.Run("matching resource tags are copied onto the alarms", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := sqsConfig()
+		require.NoError(t, config.ApplyTags(cfg, map[string]string{
+			"AWS_AUTO_ALARM_ENABLED": "true",
+			"AWS_AUTO_ALARM_TAGS":    `{"env":"prod"}`,
+			"team":                   "payments",
+			"env":                    "staging",
+			"cost-center":            "1234",
+			"owner":                  "someone",
+		}))
+		cfg.PropagateTags = []string{"team", "env", "cost-*", "AWS_AUTO_ALARM_*"}
+
+		alarms, err := NewFileLoader(context.TODO(), cfg, stubMapper{}, src).Load(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, alarms, 1)
+
+		tags := make(map[string]string)
+		for _, tag := range alarms[0].Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		assert.Equal(t, map[string]string{
+			"AWS_AUTO_ALARM_MANAGED":    "true",
+			"AWS_AUTO_ALARM_SOURCE_ARN": "arn:aws:sqs:us-east-1:123456789012:my-queue",
+			"team":                      "payments",
+			"env":                       "prod",
+			"cost-center":               "1234",
+		}, tags)
+	})
+
+	t.Run("propagated tags over the tag limit are dropped in order", func(t *testing.T) {
+		t.Parallel()
+
+		resourceTags := make(map[string]string)
+		for i := 0; i < MaxAlarmTags; i++ {
+			resourceTags[fmt.Sprintf("team-%02d", i)] = "payments"
+		}
+		cfg := sqsConfig()
+		require.NoError(t, config.ApplyTags(cfg, resourceTags))
+		cfg.PropagateTags = []string{"team-*"}
+
+		alarms, err := NewFileLoader(context.TODO(), cfg, stubMapper{}, src).Load(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, alarms, 1)
+
+		tags := make(map[string]string)
+		for _, tag := range alarms[0].Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		assert.Len(t, tags, MaxAlarmTags-reservedAlarmTags+2)
+		assert.Contains(t, tags, "team-45")
+		assert.NotContains(t, tags, "team-46")
+	})
+
+	t.Run("configured tags over the tag limit return a ConfigError", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := sqsConfig()
+		cfg.Tags = make(map[string]string)
+		for i := 0; i < MaxAlarmTags; i++ {
+			cfg.Tags[fmt.Sprintf("team-%02d", i)] = "payments"
+		}
+
+		_, err := NewFileLoader(context.TODO(), cfg, stubMapper{}, src).Load(context.TODO())
+
+		var cErr *ConfigError
+		require.ErrorAs(t, err, &cErr)
+		assert.ErrorContains(t, err, "52 tags is more than the limit of 50")
+	})
+}
+
+func TestFileLoader_Load_metricMath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("renders the metric queries of the lambda error rate", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := &config.Config{
+			ParsedARN: arn.ARN{
+				Partition: "aws",
+				Service:   "lambda",
+				Region:    "us-east-1",
+				AccountID: "123456789012",
+				Resource:  "function:my-function:live",
+			},
+			Include: []string{"lambda/error-rate"},
+		}
+		mapper := stubMapper{"FunctionName": "my-function", "Qualifier": "live"}
+
+		alarms, err := NewFileLoader(context.TODO(), cfg, mapper, nil).Load(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, alarms, 1)
+
+		alarm := alarms[0]
+		assert.Equal(t, "AWS/Lambda Errors/Invocations > 5% FunctionName=my-function Resource=my-function:live", aws.ToString(alarm.AlarmName))
+		assert.Nil(t, alarm.MetricName)
+		require.Len(t, alarm.Metrics, 3)
+
+		assert.Equal(t, "errorRate", aws.ToString(alarm.Metrics[0].Id))
+		assert.Equal(t, "errors / invocations * 100", aws.ToString(alarm.Metrics[0].Expression))
+		assert.True(t, aws.ToBool(alarm.Metrics[0].ReturnData))
+		for i, metric := range []string{"Errors", "Invocations"} {
+			query := alarm.Metrics[i+1]
+			assert.Equal(t, queryID(metric), aws.ToString(query.Id))
+			assert.Equal(t, metric, aws.ToString(query.MetricStat.Metric.MetricName))
+			assert.Equal(t, []types.Dimension{
+				{Name: aws.String("FunctionName"), Value: aws.String("my-function")},
+				{Name: aws.String("Resource"), Value: aws.String("my-function:live")},
+			}, query.MetricStat.Metric.Dimensions)
+			assert.False(t, aws.ToBool(query.ReturnData))
+		}
+	})
+
+	t.Run("queryID returns valid query IDs", func(t *testing.T) {
+		t.Parallel()
+
+		src := fstest.MapFS{
+			"sqs/ids.json.tmpl": {Data: []byte(`{
+				"AlarmName": "ids",
+				"Metrics": [
+					{"Id": "{{ queryID "NumberOfMessagesDeleted" }}", "Expression": "1"},
+					{"Id": "{{ queryID "5xx-errors" }}", "Expression": "1", "ReturnData": false},
+					{"Id": "{{ queryID "Errors p99.9" }}", "Expression": "1", "ReturnData": false},
+					{"Id": "{{ queryID "" }}", "Expression": "1", "ReturnData": false}
+				]
+			}`)},
+		}
+
+		alarms, err := NewFileLoader(context.TODO(), sqsConfig(), stubMapper{}, src).Load(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, alarms, 1)
+
+		ids := make([]string, 0)
+		for _, query := range alarms[0].Metrics {
+			ids = append(ids, aws.ToString(query.Id))
+		}
+		assert.Equal(t, []string{"numberOfMessagesDeleted", "m5xx_errors", "errors_p99_9", "m"}, ids)
+	})
+	t.Run("invalid metric queries return a ConfigError", func(t *testing.T) {
+		t.Parallel()
+
+		src := fstest.MapFS{
+			"sqs/ratio.json.tmpl": {Data: []byte(`{
+				"AlarmName": "ratio",
+				"Metrics": [
+					{"Id": "ratio", "Expression": "deleted / received"},
+					{"Id": "deleted", "MetricStat": {"Metric": {"MetricName": "NumberOfMessagesDeleted"}, "Period": 60, "Stat": "Sum"}},
+					{"Id": "Received", "ReturnData": false}
+				]
+			}`)},
+		}
+
+		_, err := NewFileLoader(context.TODO(), sqsConfig(), stubMapper{}, src).Load(context.TODO())
+		_, findErr := NewFileFinder(context.TODO(), sqsConfig(), stubMapper{}, src).Find(context.TODO())
+
+		var cErr *ConfigError
+		require.ErrorAs(t, err, &cErr)
+		assert.Equal(t, []string{
+			`alarm "ratio" has metric query ID "Received": IDs start with a lowercase letter and have only letters, numbers and underscores`,
+			`alarm "ratio" metric query "Received" must have one of Expression or MetricStat`,
+			`alarm "ratio" has 2 metric queries with ReturnData, want exactly 1`,
+		}, cErr.Problems)
+		assert.ErrorAs(t, findErr, &cErr)
+	})
+}
@@ -0,0 +1,136 @@
+package template
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// memFS is a read-only, in-memory fs.FS of file contents by path, such as sqs/messages-visible.json.tmpl.
+// Directories are implied by the paths of the files.
+type memFS map[string][]byte
+
+// Open opens the named file or directory.
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if b, ok := m[name]; ok {
+		return &memFile{Reader: bytes.NewReader(b), info: memInfo{name: path.Base(name), size: int64(len(b))}}, nil
+	}
+
+	entries, err := m.ReadDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &memDir{info: memInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// ReadDir returns the entries of the named directory, sorted by name. The root directory always exists.
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+
+	children := make(map[string]fs.DirEntry)
+	for file, b := range m {
+		rest, ok := strings.CutPrefix(file, prefix)
+		if !ok {
+			continue
+		}
+
+		child, _, isDir := strings.Cut(rest, "/")
+		info := memInfo{name: child, dir: isDir}
+		if !isDir {
+			info.size = int64(len(b))
+		}
+		children[child] = fs.FileInfoToDirEntry(info)
+	}
+
+	if len(children) == 0 && name != "." {
+		if _, ok := m[name]; ok {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, entry := range children {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	return entries, nil
+}
+
+// memInfo is the fs.FileInfo of a memFS file or directory.
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+
+	return 0o444
+}
+
+// memFile is an open memFS file.
+type memFile struct {
+	*bytes.Reader
+	info memInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an open memFS directory.
+type memDir struct {
+	info    memInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir returns the next n entries of the directory, or all the remaining entries when n <= 0.
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(rest))
+	d.offset += n
+
+	return rest[:n], nil
+}
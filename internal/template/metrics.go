@@ -0,0 +1,55 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// queryIDPattern matches the metric query IDs that CloudWatch accepts.
+var queryIDPattern = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+// validMetrics returns a *ConfigError if the metric math queries of the alarm would be rejected by CloudWatch.
+// CloudWatch alarms on the one query that returns data, and a query without ReturnData returns data.
+func validMetrics(alarm *cloudwatch.PutMetricAlarmInput) error {
+	if len(alarm.Metrics) == 0 {
+		return nil
+	}
+
+	name := aws.ToString(alarm.AlarmName)
+	problems := make([]string, 0)
+	if aws.ToString(alarm.MetricName) != "" || aws.ToString(alarm.Namespace) != "" {
+		problems = append(problems, fmt.Sprintf("alarm %q has both a metric and Metrics", name))
+	}
+
+	ids := make(map[string]bool)
+	returned := 0
+	for _, query := range alarm.Metrics {
+		id := aws.ToString(query.Id)
+		switch {
+		case !queryIDPattern.MatchString(id):
+			problems = append(problems, fmt.Sprintf("alarm %q has metric query ID %q: IDs start with a lowercase letter and have only letters, numbers and underscores", name, id))
+		case ids[id]:
+			problems = append(problems, fmt.Sprintf("alarm %q has more than one metric query with ID %q", name, id))
+		}
+		ids[id] = true
+
+		if (query.Expression == nil) == (query.MetricStat == nil) {
+			problems = append(problems, fmt.Sprintf("alarm %q metric query %q must have one of Expression or MetricStat", name, id))
+		}
+		if query.ReturnData == nil || *query.ReturnData {
+			returned++
+		}
+	}
+	if returned != 1 {
+		problems = append(problems, fmt.Sprintf("alarm %q has %d metric queries with ReturnData, want exactly 1", name, returned))
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// CloudWatch limits, in characters, for the alarm name and description.
+const (
+	MaxAlarmNameLength        = 255
+	MaxAlarmDescriptionLength = 1024
+)
+
+// MaxAlarmTags is the CloudWatch limit on the number of tags of an alarm.
+const MaxAlarmTags = 50
+
+// reservedAlarmTags is the number of AWS_AUTO_ALARM_ tags that an alarm can have besides the propagated and
+// configured tags: MANAGED, MUTED_UNTIL, SOURCE_ARN and SEVERITY.
+const reservedAlarmTags = 4
+
+// hashLength is the number of hex characters of the hash that ends a shortened name or description.
+const hashLength = 8
+
+// limitAlarm validates the name, the number of tags and the metric math queries of the alarm against the CloudWatch
+// rules and shortens the name and description when they are longer than the CloudWatch limits. See shorten and
+// validMetrics. Too many tags is a *ConfigError, since the configured tags alone are more than the limit.
+func limitAlarm(alarm *cloudwatch.PutMetricAlarmInput) error {
+	name := aws.ToString(alarm.AlarmName)
+	if err := validName(name); err != nil {
+		return fmt.Errorf("alarm %q: %w", name, err)
+	}
+	if len(alarm.Tags) > MaxAlarmTags {
+		return &ConfigError{Problems: []string{
+			fmt.Sprintf("alarm %q: %d tags is more than the limit of %d", name, len(alarm.Tags), MaxAlarmTags),
+		}}
+	}
+	if err := validMetrics(alarm); err != nil {
+		return err
+	}
+
+	if short := shorten(name, MaxAlarmNameLength); short != name {
+		alarm.AlarmName = aws.String(short)
+	}
+	if desc := aws.ToString(alarm.AlarmDescription); desc != "" {
+		if short := shorten(desc, MaxAlarmDescriptionLength); short != desc {
+			alarm.AlarmDescription = aws.String(short)
+		}
+	}
+
+	return nil
+}
+
+// validName returns an error if the name is empty, is not UTF-8 or has control characters.
+func validName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("name is empty")
+	}
+	if !utf8.ValidString(name) {
+		return errors.New("name is not valid UTF-8")
+	}
+	if strings.ContainsFunc(name, unicode.IsControl) {
+		return errors.New("name has control characters")
+	}
+
+	return nil
+}
+
+// shorten returns s if it has at most limit characters. Otherwise, it truncates s and appends a short hash of the
+// full value, so the same value is always shortened the same way and values that share a prefix stay distinct.
+func shorten(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	sum := sha256.Sum256([]byte(s))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+	head := strings.TrimRightFunc(string(runes[:limit-hashLength-1]), unicode.IsSpace)
+
+	return head + " " + hash
+}
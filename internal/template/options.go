@@ -0,0 +1,133 @@
+package template
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+// ConfigError lists the template overrides and selections that do not match a template or an alarm field, and the
+// problems with the metric math queries of a rendered alarm.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid template config: %s", strings.Join(e.Problems, "; "))
+}
+
+// optInTemplates are the IDs of the templates that are only rendered when the include list selects them, such as
+// the metric math examples.
+var optInTemplates = []string{"lambda/error-rate", "sqs/delete-ratio"}
+
+// templateOptions are the config.Config options that apply to templates by their ID.
+type templateOptions struct {
+	overrides templateOverrides
+	include   []string
+	exclude   []string
+	// all selects every template, including the optInTemplates, and ignores the include and exclude lists.
+	all bool
+}
+
+// newTemplateOptions returns the template overrides, include and exclude lists of the config.Config. A *ConfigError
+// is returned for any of them that do not match a template in the sources.
+func newTemplateOptions(sources []fs.FS, cfg *config.Config) (*templateOptions, error) {
+	overrides, problems := newTemplateOverrides(sources, cfg.Overrides)
+	problems = append(problems, unknownSelections(sources, "include", cfg.Include)...)
+	problems = append(problems, unknownSelections(sources, "exclude", cfg.Exclude)...)
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return nil, &ConfigError{Problems: problems}
+	}
+
+	return &templateOptions{
+		overrides: overrides,
+		include:   cfg.Include,
+		exclude:   cfg.Exclude,
+	}, nil
+}
+
+// newDeleteOptions returns the template overrides of the config.Config and selects every template, so that the alarms
+// of a template that was excluded after they were created are still found for deletion. A *ConfigError is returned
+// for overrides that do not match a template in the sources.
+func newDeleteOptions(sources []fs.FS, cfg *config.Config) (*templateOptions, error) {
+	overrides, problems := newTemplateOverrides(sources, cfg.Overrides)
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return nil, &ConfigError{Problems: problems}
+	}
+
+	return &templateOptions{overrides: overrides, all: true}, nil
+}
+
+// selected returns true if the template is in the include list, or the include list is empty, and it is not in the
+// exclude list. The optInTemplates must be in the include list.
+func (o *templateOptions) selected(id string) bool {
+	if o.all {
+		return true
+	}
+	if matchesTemplate(o.exclude, id) {
+		return false
+	}
+	if len(o.include) == 0 {
+		return !slices.Contains(optInTemplates, id)
+	}
+
+	return matchesTemplate(o.include, id)
+}
+
+// apply sets the override fields of the template on each alarm that it rendered.
+func (o *templateOptions) apply(id string, alarms []*cloudwatch.PutMetricAlarmInput) error {
+	return o.overrides.apply(id, alarms)
+}
+
+// matchesTemplate returns true if any entry is the template ID, such as sqs/dlq-messages-visible, or the template
+// name without its service, such as dlq-messages-visible.
+func matchesTemplate(entries []string, id string) bool {
+	name := path.Base(id)
+	for _, entry := range entries {
+		if entry == id || entry == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unknownSelections returns a problem for each entry of the list that does not match a template of any service in
+// the sources.
+func unknownSelections(sources []fs.FS, list string, entries []string) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ids := allTemplateIDs(sources)
+	problems := make([]string, 0)
+	for _, entry := range entries {
+		if !slices.ContainsFunc(ids, func(id string) bool { return matchesTemplate([]string{entry}, id) }) {
+			problems = append(problems, fmt.Sprintf("%s %s does not match a template", list, entry))
+		}
+	}
+
+	return problems
+}
+
+// allTemplateIDs returns the IDs of the templates of every service in the sources.
+func allTemplateIDs(sources []fs.FS) []string {
+	ids := make([]string, 0)
+	for _, src := range sources {
+		matches, _ := fs.Glob(src, "*/*")
+		for _, match := range matches {
+			ids = append(ids, templateID(path.Dir(match), path.Base(match)))
+		}
+	}
+
+	return ids
+}
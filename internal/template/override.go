@@ -0,0 +1,128 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// templateOverrides are alarm field values by template ID, such as sqs/messages-visible.
+type templateOverrides map[string]map[string]any
+
+// stringDataOverrides are the overrides that are template data and must be strings, such as the dead-letter queue names
+// that the resources package reads.
+var stringDataOverrides = []string{"SQS_DLQ_NAME", "EVENTS_DLQ_NAME"}
+
+// templateID returns the ID of a template file, which is its service directory and its file name up to the first
+// dot, such as sqs/messages-visible for sqs/messages-visible.json.tmpl.
+func templateID(service, name string) string {
+	id, _, _ := strings.Cut(name, ".")
+	return service + "/" + id
+}
+
+// newTemplateOverrides returns the config.Config overrides that are addressed by template ID, such as
+// {"sqs/messages-visible": {"Threshold": 1000}}. Other overrides, such as SQS_DLQ_NAME, are template data and are
+// skipped. The problems list IDs that are not in the sources, fields that are not in a cloudwatch.PutMetricAlarmInput
+// or cannot hold the value, and stringDataOverrides that are not strings.
+func newTemplateOverrides(sources []fs.FS, overrides map[string]any) (templateOverrides, []string) {
+	out := make(templateOverrides)
+	problems := make([]string, 0)
+	ids := make(map[string][]string)
+
+	for key, value := range overrides {
+		service, _, found := strings.Cut(key, "/")
+		if !found {
+			if _, ok := value.(string); !ok && slices.Contains(stringDataOverrides, key) {
+				problems = append(problems, fmt.Sprintf("override %s must be a string, not %v", key, value))
+			}
+			continue
+		}
+
+		if _, ok := ids[service]; !ok {
+			ids[service] = serviceTemplateIDs(sources, service)
+		}
+		if !slices.Contains(ids[service], key) {
+			problems = append(problems, fmt.Sprintf("override %s does not match a template", key))
+			continue
+		}
+
+		fields, ok := value.(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("override %s must be an object of alarm fields", key))
+			continue
+		}
+
+		for field, fieldValue := range fields {
+			if err := validOverride(field, fieldValue); err != nil {
+				problems = append(problems, fmt.Sprintf("override %s.%s: %s", key, field, err))
+			}
+		}
+		out[key] = fields
+	}
+
+	return out, problems
+}
+
+// apply sets the override fields of the template on each alarm that it rendered. An overridden name or description
+// is held to the same limits as a rendered one.
+func (o templateOverrides) apply(id string, alarms []*cloudwatch.PutMetricAlarmInput) error {
+	fields, ok := o[id]
+	if !ok {
+		return nil
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("unable to marshal overrides for %s: %w", id, err)
+	}
+	for _, alarm := range alarms {
+		if err = json.Unmarshal(b, alarm); err != nil {
+			return fmt.Errorf("unable to apply overrides for %s: %w", id, err)
+		}
+		if err = limitAlarm(alarm); err != nil {
+			return fmt.Errorf("unable to apply overrides for %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// serviceTemplateIDs returns the IDs of the templates for the service, from the first source that has any.
+func serviceTemplateIDs(sources []fs.FS, service string) []string {
+	src, pattern, err := serviceSource(sources, service)
+	if err != nil {
+		return nil
+	}
+
+	matches, _ := fs.Glob(src, pattern)
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, templateID(service, path.Base(match)))
+	}
+
+	return ids
+}
+
+// validOverride returns an error if the field is not in a cloudwatch.PutMetricAlarmInput or cannot hold the value.
+func validOverride(field string, value any) error {
+	f, ok := reflect.TypeOf(cloudwatch.PutMetricAlarmInput{}).FieldByName(field)
+	if !ok || !f.IsExported() {
+		return fmt.Errorf("unknown alarm field")
+	}
+
+	b, err := json.Marshal(map[string]any{field: value})
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, new(cloudwatch.PutMetricAlarmInput)); err != nil {
+		return fmt.Errorf("invalid value %v", value)
+	}
+
+	return nil
+}
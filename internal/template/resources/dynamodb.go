@@ -0,0 +1,107 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+const (
+	// capacityAlarmPeriod is the period, in seconds, used by the consumed capacity alarms.
+	capacityAlarmPeriod = 60
+	// capacityAlarmUtilization is the fraction of provisioned capacity that triggers the consumed capacity alarms.
+	capacityAlarmUtilization = 0.8
+)
+
+// GlobalSecondaryIndex is the template data for a single global secondary index of a DynamoDB table.
+type GlobalSecondaryIndex struct {
+	// IndexName is the name of the global secondary index.
+	IndexName string
+	// ReadCapacityThreshold is the consumed read capacity, per alarm period, that triggers an alarm.
+	// It is zero when the index does not have provisioned capacity.
+	ReadCapacityThreshold float64
+	// WriteCapacityThreshold is the consumed write capacity, per alarm period, that triggers an alarm.
+	// It is zero when the index does not have provisioned capacity.
+	WriteCapacityThreshold float64
+}
+
+// dynamodbResources returns a resourceMapFn that adds table information to the map.
+// When api is not nil, the table is described to find its provisioned capacity and global secondary indexes.
+func dynamodbResources(api autoalarm.DescribeTableAPI) resourceMapFn {
+	return func(ctx context.Context, cfg *config.Config, m map[string]any) error {
+		a := cfg.ParsedARN
+		if a.Service != "dynamodb" {
+			return nil
+		}
+
+		table, err := tableName(a)
+		if err != nil {
+			return err
+		}
+		m["TableName"] = table
+		m["ReadCapacityThreshold"] = float64(0)
+		m["WriteCapacityThreshold"] = float64(0)
+		m["GlobalSecondaryIndexes"] = make([]GlobalSecondaryIndex, 0)
+
+		if api == nil {
+			return nil
+		}
+
+		out, err := api.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+		if err != nil {
+			return fmt.Errorf("unable to describe table %s: %w", table, err)
+		}
+		if out.Table == nil {
+			return nil
+		}
+
+		m["ReadCapacityThreshold"], m["WriteCapacityThreshold"] = capacityThresholds(out.Table.ProvisionedThroughput)
+
+		indexes := make([]GlobalSecondaryIndex, 0, len(out.Table.GlobalSecondaryIndexes))
+		for _, gsi := range out.Table.GlobalSecondaryIndexes {
+			read, write := capacityThresholds(gsi.ProvisionedThroughput)
+			indexes = append(indexes, GlobalSecondaryIndex{
+				IndexName:              aws.ToString(gsi.IndexName),
+				ReadCapacityThreshold:  read,
+				WriteCapacityThreshold: write,
+			})
+		}
+		m["GlobalSecondaryIndexes"] = indexes
+
+		return nil
+	}
+}
+
+// tableName parses the table name from resources such as table/my-table or table/my-table/stream/<label>.
+func tableName(a arn.ARN) (string, error) {
+	resource, ok := strings.CutPrefix(a.Resource, "table/")
+	if !ok || resource == "" {
+		return "", fmt.Errorf("unsupported dynamodb resource: %s", a.Resource)
+	}
+
+	table, _, _ := strings.Cut(resource, "/")
+
+	return table, nil
+}
+
+// capacityThresholds returns the read and write capacity thresholds for the provisioned throughput.
+// On-demand tables and indexes report zero provisioned capacity, which results in zero thresholds.
+func capacityThresholds(pt *types.ProvisionedThroughputDescription) (float64, float64) {
+	if pt == nil {
+		return 0, 0
+	}
+
+	threshold := func(units *int64) float64 {
+		return float64(aws.ToInt64(units)) * capacityAlarmPeriod * capacityAlarmUtilization
+	}
+
+	return threshold(pt.ReadCapacityUnits), threshold(pt.WriteCapacityUnits)
+}
@@ -0,0 +1,160 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+type fakeDescribeTableAPI struct {
+	out *dynamodb.DescribeTableOutput
+	err error
+}
+
+func (f *fakeDescribeTableAPI) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return f.out, f.err
+}
+
+func Test_dynamodbResources(t *testing.T) {
+	t.Parallel()
+
+	tableARN := arn.ARN{
+		Service:  "dynamodb",
+		Resource: "table/my-table",
+	}
+
+	cases := map[string]struct {
+		arn     arn.ARN
+		api     autoalarm.DescribeTableAPI
+		given   map[string]any
+		wanted  map[string]any
+		wantErr bool
+	}{
+		"does not modify map when service is not DynamoDB": {
+			arn: arn.ARN{
+				Service: "sqs",
+			},
+			given: map[string]any{
+				"Foo": "Bar",
+			},
+			wanted: map[string]any{
+				"Foo": "Bar",
+			},
+		},
+		"adds table info to map without api": {
+			arn:   tableARN,
+			given: map[string]any{},
+			wanted: map[string]any{
+				"TableName":              "my-table",
+				"ReadCapacityThreshold":  float64(0),
+				"WriteCapacityThreshold": float64(0),
+				"GlobalSecondaryIndexes": []GlobalSecondaryIndex{},
+			},
+		},
+		"adds capacity and indexes from api": {
+			arn: tableARN,
+			api: &fakeDescribeTableAPI{
+				out: &dynamodb.DescribeTableOutput{
+					Table: &types.TableDescription{
+						ProvisionedThroughput: &types.ProvisionedThroughputDescription{
+							ReadCapacityUnits:  aws.Int64(10),
+							WriteCapacityUnits: aws.Int64(5),
+						},
+						GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{
+							{
+								IndexName: aws.String("by-owner"),
+								ProvisionedThroughput: &types.ProvisionedThroughputDescription{
+									ReadCapacityUnits:  aws.Int64(20),
+									WriteCapacityUnits: aws.Int64(0),
+								},
+							},
+							{
+								IndexName: aws.String("by-date"),
+							},
+						},
+					},
+				},
+			},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"TableName":              "my-table",
+				"ReadCapacityThreshold":  float64(480),
+				"WriteCapacityThreshold": float64(240),
+				"GlobalSecondaryIndexes": []GlobalSecondaryIndex{
+					{
+						IndexName:             "by-owner",
+						ReadCapacityThreshold: 960,
+					},
+					{
+						IndexName: "by-date",
+					},
+				},
+			},
+		},
+		"returns api errors": {
+			arn:     tableARN,
+			api:     &fakeDescribeTableAPI{err: errors.New("boom")},
+			given:   map[string]any{},
+			wantErr: true,
+		},
+		"returns error for unsupported resource": {
+			arn: arn.ARN{
+				Service:  "dynamodb",
+				Resource: "global-table/my-table",
+			},
+			given:   map[string]any{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := dynamodbResources(tc.api)(context.TODO(), &config.Config{ParsedARN: tc.arn}, tc.given)
+
+			assert.Equal(t, tc.wantErr, err != nil)
+			if !tc.wantErr {
+				assert.Equal(t, tc.wanted, tc.given)
+			}
+		})
+	}
+}
+
+func Test_tableName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		resource string
+		want     string
+		wantErr  bool
+	}{
+		"table":        {resource: "table/my-table", want: "my-table"},
+		"table stream": {resource: "table/my-table/stream/2024-01-01T00:00:00.000", want: "my-table"},
+		"table index":  {resource: "table/my-table/index/by-owner", want: "my-table"},
+		"missing name": {resource: "table/", wantErr: true},
+		"not a table":  {resource: "backup/my-backup", wantErr: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tableName(arn.ARN{Resource: tc.resource})
+
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
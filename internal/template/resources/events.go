@@ -0,0 +1,60 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+const defaultEventBusName = "default"
+
+// eventsResources adds the rule and event bus names of an EventBridge rule. The dead-letter queue of a rule is not
+// known from its ARN, so DLQName is only set by the EVENTS_DLQ_NAME override, and is empty otherwise.
+func eventsResources(_ context.Context, cfg *config.Config, m map[string]any) error {
+	arn := cfg.ParsedARN
+	if arn.Service != "events" {
+		return nil
+	}
+
+	rule, bus, err := ruleNames(arn)
+	if err != nil {
+		return err
+	}
+
+	dlq, _, err := stringOverride(cfg.Overrides, "EVENTS_DLQ_NAME")
+	if err != nil {
+		return err
+	}
+
+	m["RuleName"] = rule
+	m["EventBusName"] = bus
+	m["DLQName"] = dlq
+
+	return nil
+}
+
+// ruleNames parses the rule and event bus names from resources in the formats rule/<name> and rule/<bus>/<name>.
+// Rules on the default event bus do not include the bus name.
+// Partner event bus names contain slashes, so the rule name is always the final segment.
+func ruleNames(a arn.ARN) (string, string, error) {
+	resource, ok := strings.CutPrefix(a.Resource, "rule/")
+	if !ok || resource == "" {
+		return "", "", fmt.Errorf("unsupported events resource: %s", a.Resource)
+	}
+
+	bus := defaultEventBusName
+	rule := resource
+	if i := strings.LastIndex(resource, "/"); i >= 0 {
+		bus, rule = resource[:i], resource[i+1:]
+	}
+
+	if bus == "" || rule == "" {
+		return "", "", fmt.Errorf("unsupported events resource: %s", a.Resource)
+	}
+
+	return rule, bus, nil
+}
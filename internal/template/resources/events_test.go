@@ -0,0 +1,154 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+func Test_eventsResources(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		cfg     *config.Config
+		given   map[string]any
+		wanted  map[string]any
+		wantErr bool
+	}{
+		"does not modify map when service is not EventBridge": {
+			cfg: &config.Config{
+				ParsedARN: arn.ARN{
+					Service: "sqs",
+				},
+			},
+			given: map[string]any{
+				"Foo": "Bar",
+			},
+			wanted: map[string]any{
+				"Foo": "Bar",
+			},
+		},
+		"adds rule info to map without a dlq": {
+			cfg: &config.Config{
+				ParsedARN: arn.ARN{
+					Service:  "events",
+					Resource: "rule/my-bus/my-rule",
+				},
+			},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"RuleName":     "my-rule",
+				"EventBusName": "my-bus",
+				"DLQName":      "",
+			},
+		},
+		"override dlq is used": {
+			cfg: &config.Config{
+				ParsedARN: arn.ARN{
+					Service:  "events",
+					Resource: "rule/my-rule",
+				},
+				Overrides: map[string]any{
+					"EVENTS_DLQ_NAME": "use-this-one",
+				},
+			},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"RuleName":     "my-rule",
+				"EventBusName": "default",
+				"DLQName":      "use-this-one",
+			},
+		},
+		"returns error for override dlq that is not a string": {
+			cfg: &config.Config{
+				ParsedARN: arn.ARN{
+					Service:  "events",
+					Resource: "rule/my-rule",
+				},
+				Overrides: map[string]any{
+					"EVENTS_DLQ_NAME": float64(1),
+				},
+			},
+			given:   map[string]any{},
+			wantErr: true,
+		},
+		"returns error for unsupported resource": {
+			cfg: &config.Config{
+				ParsedARN: arn.ARN{
+					Service:  "events",
+					Resource: "event-bus/my-bus",
+				},
+			},
+			given:   map[string]any{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := eventsResources(context.TODO(), tc.cfg, tc.given)
+
+			assert.Equal(t, tc.wantErr, err != nil)
+			if !tc.wantErr {
+				assert.Equal(t, tc.wanted, tc.given)
+			}
+		})
+	}
+}
+
+func Test_ruleNames(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		resource string
+		wantRule string
+		wantBus  string
+		wantErr  bool
+	}{
+		"default bus": {
+			resource: "rule/my-rule",
+			wantRule: "my-rule",
+			wantBus:  "default",
+		},
+		"custom bus": {
+			resource: "rule/my-bus/my-rule",
+			wantRule: "my-rule",
+			wantBus:  "my-bus",
+		},
+		"partner bus": {
+			resource: "rule/aws.partner/example.com/123/my-rule",
+			wantRule: "my-rule",
+			wantBus:  "aws.partner/example.com/123",
+		},
+		"missing rule": {
+			resource: "rule/my-bus/",
+			wantErr:  true,
+		},
+		"not a rule": {
+			resource: "event-bus/my-bus",
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, bus, err := ruleNames(arn.ARN{Resource: tc.resource})
+
+			assert := assert.New(t)
+
+			assert.Equal(tc.wantErr, err != nil)
+			assert.Equal(tc.wantRule, rule)
+			assert.Equal(tc.wantBus, bus)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+const (
+	// durationAlarmUtilization is the fraction of the function timeout that triggers the duration alarm.
+	durationAlarmUtilization = 0.8
+	// concurrencyAlarmUtilization is the fraction of the concurrency limit that triggers the concurrency alarm.
+	concurrencyAlarmUtilization = 0.8
+	// defaultConcurrencyLimit is the default regional concurrency quota, used when a function has no reserved
+	// concurrency.
+	defaultConcurrencyLimit = 1000
+)
+
+// lambdaResources returns a resourceMapFn that adds function information to the map.
+// When api is not nil, the function is fetched to find its timeout and reserved concurrency.
+func lambdaResources(api autoalarm.GetFunctionAPI) resourceMapFn {
+	return func(ctx context.Context, cfg *config.Config, m map[string]any) error {
+		a := cfg.ParsedARN
+		if a.Service != "lambda" {
+			return nil
+		}
+
+		function, qualifier, err := functionNames(a)
+		if err != nil {
+			return err
+		}
+		m["FunctionName"] = function
+		m["Qualifier"] = qualifier
+		m["DurationThreshold"] = float64(0)
+		m["ConcurrencyThreshold"] = float64(defaultConcurrencyLimit * concurrencyAlarmUtilization)
+
+		if api == nil {
+			return nil
+		}
+
+		in := &lambda.GetFunctionInput{FunctionName: aws.String(function)}
+		if qualifier != "" {
+			in.Qualifier = aws.String(qualifier)
+		}
+
+		out, err := api.GetFunction(ctx, in)
+		if err != nil {
+			return fmt.Errorf("unable to get function %s: %w", function, err)
+		}
+
+		if out.Configuration != nil && out.Configuration.Timeout != nil {
+			timeoutMillis := float64(aws.ToInt32(out.Configuration.Timeout)) * 1000
+			m["DurationThreshold"] = timeoutMillis * durationAlarmUtilization
+		}
+		if out.Concurrency != nil && out.Concurrency.ReservedConcurrentExecutions != nil {
+			reserved := float64(aws.ToInt32(out.Concurrency.ReservedConcurrentExecutions))
+			m["ConcurrencyThreshold"] = reserved * concurrencyAlarmUtilization
+		}
+
+		return nil
+	}
+}
+
+// functionNames parses the function name and optional qualifier, an alias or version, from resources in the formats
+// function:<name> and function:<name>:<qualifier>.
+func functionNames(a arn.ARN) (string, string, error) {
+	resource, ok := strings.CutPrefix(a.Resource, "function:")
+	if !ok || resource == "" {
+		return "", "", fmt.Errorf("unsupported lambda resource: %s", a.Resource)
+	}
+
+	function, qualifier, _ := strings.Cut(resource, ":")
+	if function == "" || strings.Contains(qualifier, ":") {
+		return "", "", fmt.Errorf("unsupported lambda resource: %s", a.Resource)
+	}
+
+	return function, qualifier, nil
+}
@@ -0,0 +1,159 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+type fakeGetFunctionAPI struct {
+	out *lambda.GetFunctionOutput
+	err error
+	in  *lambda.GetFunctionInput
+}
+
+func (f *fakeGetFunctionAPI) GetFunction(_ context.Context, in *lambda.GetFunctionInput, _ ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+	f.in = in
+	return f.out, f.err
+}
+
+func Test_lambdaResources(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		arn     arn.ARN
+		api     autoalarm.GetFunctionAPI
+		given   map[string]any
+		wanted  map[string]any
+		wantErr bool
+	}{
+		"does not modify map when service is not Lambda": {
+			arn: arn.ARN{
+				Service: "sqs",
+			},
+			given: map[string]any{
+				"Foo": "Bar",
+			},
+			wanted: map[string]any{
+				"Foo": "Bar",
+			},
+		},
+		"adds function info to map without api": {
+			arn: arn.ARN{
+				Service:  "lambda",
+				Resource: "function:my-function",
+			},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"FunctionName":         "my-function",
+				"Qualifier":            "",
+				"DurationThreshold":    float64(0),
+				"ConcurrencyThreshold": float64(800),
+			},
+		},
+		"adds timeout and reserved concurrency from api": {
+			arn: arn.ARN{
+				Service:  "lambda",
+				Resource: "function:my-function:live",
+			},
+			api: &fakeGetFunctionAPI{
+				out: &lambda.GetFunctionOutput{
+					Configuration: &types.FunctionConfiguration{
+						Timeout: aws.Int32(30),
+					},
+					Concurrency: &types.Concurrency{
+						ReservedConcurrentExecutions: aws.Int32(10),
+					},
+				},
+			},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"FunctionName":         "my-function",
+				"Qualifier":            "live",
+				"DurationThreshold":    float64(24000),
+				"ConcurrencyThreshold": float64(8),
+			},
+		},
+		"returns api errors": {
+			arn: arn.ARN{
+				Service:  "lambda",
+				Resource: "function:my-function",
+			},
+			api:     &fakeGetFunctionAPI{err: errors.New("boom")},
+			given:   map[string]any{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := lambdaResources(tc.api)(context.TODO(), &config.Config{ParsedARN: tc.arn}, tc.given)
+
+			assert.Equal(t, tc.wantErr, err != nil)
+			if !tc.wantErr {
+				assert.Equal(t, tc.wanted, tc.given)
+			}
+		})
+	}
+}
+
+func Test_functionNames(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		resource      string
+		wantFunction  string
+		wantQualifier string
+		wantErr       bool
+	}{
+		"unqualified": {
+			resource:     "function:my-function",
+			wantFunction: "my-function",
+		},
+		"alias": {
+			resource:      "function:my-function:live",
+			wantFunction:  "my-function",
+			wantQualifier: "live",
+		},
+		"version": {
+			resource:      "function:my-function:12",
+			wantFunction:  "my-function",
+			wantQualifier: "12",
+		},
+		"too many parts": {
+			resource: "function:my-function:live:extra",
+			wantErr:  true,
+		},
+		"not a function": {
+			resource: "layer:my-layer:1",
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			function, qualifier, err := functionNames(arn.ARN{Resource: tc.resource})
+
+			assert := assert.New(t)
+
+			assert.Equal(tc.wantErr, err != nil)
+			assert.Equal(tc.wantFunction, function)
+			assert.Equal(tc.wantQualifier, qualifier)
+		})
+	}
+}
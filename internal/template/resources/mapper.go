@@ -2,13 +2,23 @@ package resources
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
 	"github.com/akijowski/aws-auto-alarm/internal/config"
 )
 
-type resourceMapFn func(cfg *config.Config, m map[string]any)
+type resourceMapFn func(ctx context.Context, cfg *config.Config, m map[string]any) error
+
+// Clients are the AWS APIs used to look up additional information about a resource.
+// A nil API skips the lookup that depends on it.
+type Clients struct {
+	DescribeTableAPI autoalarm.DescribeTableAPI
+	GetFunctionAPI   autoalarm.GetFunctionAPI
+	QueueAPI         autoalarm.QueueAttributesAPI
+}
 
 // Mapper contains functions to generate the map for alarmData.Resources.
 type Mapper struct {
@@ -17,10 +27,17 @@ type Mapper struct {
 	fns       []resourceMapFn
 }
 
-func NewMapper(cfg *config.Config) *Mapper {
+func NewMapper(cfg *config.Config, clients *Clients) *Mapper {
+	if clients == nil {
+		clients = new(Clients)
+	}
+
 	resources := make(map[string]any)
 	fns := []resourceMapFn{
-		sqsResources,
+		sqsResources(clients.QueueAPI),
+		eventsResources,
+		dynamodbResources(clients.DescribeTableAPI),
+		lambdaResources(clients.GetFunctionAPI),
 	}
 
 	return &Mapper{
@@ -30,15 +47,34 @@ func NewMapper(cfg *config.Config) *Mapper {
 	}
 }
 
-func (m *Mapper) Map(ctx context.Context) map[string]any {
+func (m *Mapper) Map(ctx context.Context) (map[string]any, error) {
 	log.Ctx(ctx).Debug().
 		Int("functions_length", len(m.fns)).
 		Bool("has_overrides", len(m.cfg.Overrides) > 0).
 		Interface("overrides", m.cfg.Overrides).
 		Msg("Mapping resources")
 	for _, fn := range m.fns {
-		fn(m.cfg, m.resources)
+		if err := fn(ctx, m.cfg, m.resources); err != nil {
+			return nil, fmt.Errorf("unable to map resources: %w", err)
+		}
+	}
+
+	return m.resources, nil
+}
+
+// stringOverride returns the config.Config override for key and whether it is set. Overrides are parsed from JSON, so
+// an override that is not a string returns an error. The template loader reports these overrides as a ConfigError before
+// mapping, so the error is only returned to callers of the Mapper itself.
+func stringOverride(overrides map[string]any, key string) (string, bool, error) {
+	value, ok := overrides[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "", false, fmt.Errorf("override %s must be a string, not %v", key, value)
 	}
 
-	return m.resources
+	return s, true, nil
 }
@@ -2,6 +2,7 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,7 +15,7 @@ func TestNewMapper(t *testing.T) {
 
 	cfg := &config.Config{}
 
-	mapper := NewMapper(cfg)
+	mapper := NewMapper(cfg, nil)
 
 	assert := assert.New(t)
 
@@ -32,9 +33,10 @@ func TestMapper_Map(t *testing.T) {
 		t.Parallel()
 
 		fnCalled := 0
-		testFn := func(cfg *config.Config, m map[string]any) {
+		testFn := func(_ context.Context, cfg *config.Config, m map[string]any) error {
 			fnCalled++
 			m["test"] = "test"
+			return nil
 		}
 		mapper := &Mapper{
 			cfg:       &config.Config{},
@@ -43,7 +45,7 @@ func TestMapper_Map(t *testing.T) {
 				testFn,
 			},
 		}
-		_ = mapper.Map(context.TODO())
+		_, _ = mapper.Map(context.TODO())
 
 		assert.Equal(1, fnCalled)
 	})
@@ -51,8 +53,9 @@ func TestMapper_Map(t *testing.T) {
 	t.Run("returns mapped resources", func(t *testing.T) {
 		t.Parallel()
 
-		testFn := func(cfg *config.Config, m map[string]any) {
+		testFn := func(_ context.Context, cfg *config.Config, m map[string]any) error {
 			m["test"] = "test"
+			return nil
 		}
 		mapper := &Mapper{
 			cfg:       &config.Config{},
@@ -61,8 +64,27 @@ func TestMapper_Map(t *testing.T) {
 				testFn,
 			},
 		}
-		resources := mapper.Map(context.TODO())
+		resources, err := mapper.Map(context.TODO())
 
+		assert.NoError(err)
 		assert.Equal("test", resources["test"])
 	})
+
+	t.Run("returns map function errors", func(t *testing.T) {
+		t.Parallel()
+
+		testFn := func(_ context.Context, cfg *config.Config, m map[string]any) error {
+			return errors.New("boom")
+		}
+		mapper := &Mapper{
+			cfg:       &config.Config{},
+			resources: map[string]any{},
+			fns: []resourceMapFn{
+				testFn,
+			},
+		}
+		_, err := mapper.Map(context.TODO())
+
+		assert.Error(err)
+	})
 }
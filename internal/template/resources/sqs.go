@@ -1,30 +1,98 @@
 package resources
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
 	"github.com/akijowski/aws-auto-alarm/internal/config"
 )
 
-func sqsResources(cfg *config.Config, m map[string]any) {
-	arn := cfg.ParsedARN
-	if arn.Service == "sqs" {
-		queue, dlq := queueNames(arn, cfg.Overrides)
+// redrivePolicy is the JSON document stored in the RedrivePolicy attribute of a queue.
+type redrivePolicy struct {
+	DeadLetterTargetARN string `json:"deadLetterTargetArn"`
+}
+
+// sqsResources returns a resourceMapFn that adds queue information to the map.
+// When api is not nil, the dead-letter queue is read from the queue's RedrivePolicy instead of being guessed.
+// A queue without a RedrivePolicy has an empty DLQName.
+func sqsResources(api autoalarm.QueueAttributesAPI) resourceMapFn {
+	return func(ctx context.Context, cfg *config.Config, m map[string]any) error {
+		arn := cfg.ParsedARN
+		if arn.Service != "sqs" {
+			return nil
+		}
+
+		queue, dlq, err := queueNames(arn, cfg.Overrides)
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.Overrides["SQS_DLQ_NAME"]; !ok && api != nil {
+			if dlq, err = redriveDLQName(ctx, api, arn); err != nil {
+				return err
+			}
+		}
+
 		m["QueueName"] = queue
 		m["DLQName"] = dlq
+
+		return nil
 	}
 }
 
-func queueNames(a arn.ARN, overrides map[string]any) (string, string) {
+func queueNames(a arn.ARN, overrides map[string]any) (string, string, error) {
 	queue := a.Resource
-	dlq := fmt.Sprintf("%s-dlq", queue)
 
-	if dlqOverride, ok := overrides["SQS_DLQ_NAME"]; ok {
-		dlq = dlqOverride.(string)
+	dlq, ok, err := stringOverride(overrides, "SQS_DLQ_NAME")
+	if err != nil {
+		return "", "", err
 	}
+	if !ok {
+		dlq = fmt.Sprintf("%s-dlq", queue)
+	}
+
+	return queue, dlq, nil
+}
 
-	return queue, dlq
+// redriveDLQName returns the name of the dead-letter queue in the queue's RedrivePolicy, or an empty string if the
+// queue does not have one.
+func redriveDLQName(ctx context.Context, api autoalarm.QueueAttributesAPI, a arn.ARN) (string, error) {
+	urlOut, err := api.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+		QueueName:              aws.String(a.Resource),
+		QueueOwnerAWSAccountId: aws.String(a.AccountID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to get queue url for %s: %w", a.Resource, err)
+	}
+
+	attrOut, err := api.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       urlOut.QueueUrl,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameRedrivePolicy},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to get queue attributes for %s: %w", a.Resource, err)
+	}
+
+	policyStr, ok := attrOut.Attributes[string(types.QueueAttributeNameRedrivePolicy)]
+	if !ok || policyStr == "" {
+		return "", nil
+	}
+
+	policy := new(redrivePolicy)
+	if err = json.Unmarshal([]byte(policyStr), policy); err != nil {
+		return "", fmt.Errorf("unable to parse redrive policy for %s: %w", a.Resource, err)
+	}
+
+	dlqARN, err := arn.Parse(policy.DeadLetterTargetARN)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse dead-letter queue ARN for %s: %w", a.Resource, err)
+	}
 
+	return dlqARN.Resource, nil
 }
@@ -1,21 +1,52 @@
 package resources
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
 	"github.com/akijowski/aws-auto-alarm/internal/config"
 )
 
+type fakeQueueAttributesAPI struct {
+	attributes map[string]string
+	err        error
+}
+
+func (f *fakeQueueAttributesAPI) GetQueueUrl(_ context.Context, in *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sqs.GetQueueUrlOutput{
+		QueueUrl: aws.String("https://sqs.us-east-1.amazonaws.com/" + aws.ToString(in.QueueOwnerAWSAccountId) + "/" + aws.ToString(in.QueueName)),
+	}, nil
+}
+
+func (f *fakeQueueAttributesAPI) GetQueueAttributes(_ context.Context, _ *sqs.GetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{Attributes: f.attributes}, nil
+}
+
 func Test_sqsResources(t *testing.T) {
 	t.Parallel()
 
+	queueARN := arn.ARN{
+		Service:   "sqs",
+		AccountID: "123456789012",
+		Resource:  "my-queue",
+	}
+
 	cases := map[string]struct {
-		cfg    *config.Config
-		given  map[string]any
-		wanted map[string]any
+		cfg     *config.Config
+		api     autoalarm.QueueAttributesAPI
+		given   map[string]any
+		wanted  map[string]any
+		wantErr bool
 	}{
 		"does not modify map when service is not SQS": {
 			cfg: &config.Config{
@@ -43,6 +74,60 @@ func Test_sqsResources(t *testing.T) {
 				"DLQName":   "my-queue-dlq",
 			},
 		},
+		"uses dlq from redrive policy": {
+			cfg: &config.Config{ParsedARN: queueARN},
+			api: &fakeQueueAttributesAPI{
+				attributes: map[string]string{
+					"RedrivePolicy": `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:my-errors","maxReceiveCount":5}`,
+				},
+			},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"QueueName": "my-queue",
+				"DLQName":   "my-errors",
+			},
+		},
+		"empty dlq without redrive policy": {
+			cfg: &config.Config{ParsedARN: queueARN},
+			api: &fakeQueueAttributesAPI{
+				attributes: map[string]string{},
+			},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"QueueName": "my-queue",
+				"DLQName":   "",
+			},
+		},
+		"override dlq skips api": {
+			cfg: &config.Config{
+				ParsedARN: queueARN,
+				Overrides: map[string]any{
+					"SQS_DLQ_NAME": "use-this-one",
+				},
+			},
+			api:   &fakeQueueAttributesAPI{err: errors.New("should not be called")},
+			given: map[string]any{},
+			wanted: map[string]any{
+				"QueueName": "my-queue",
+				"DLQName":   "use-this-one",
+			},
+		},
+		"returns api errors": {
+			cfg:     &config.Config{ParsedARN: queueARN},
+			api:     &fakeQueueAttributesAPI{err: errors.New("boom")},
+			given:   map[string]any{},
+			wantErr: true,
+		},
+		"returns error for invalid redrive policy": {
+			cfg: &config.Config{ParsedARN: queueARN},
+			api: &fakeQueueAttributesAPI{
+				attributes: map[string]string{
+					"RedrivePolicy": `{"deadLetterTargetArn":"not-an-arn"}`,
+				},
+			},
+			given:   map[string]any{},
+			wantErr: true,
+		},
 	}
 
 	for name, tc := range cases {
@@ -50,9 +135,12 @@ func Test_sqsResources(t *testing.T) {
 			tc := tc
 			t.Parallel()
 
-			sqsResources(tc.cfg, tc.given)
+			err := sqsResources(tc.api)(context.TODO(), tc.cfg, tc.given)
 
-			assert.Equal(t, tc.wanted, tc.given)
+			assert.Equal(t, tc.wantErr, err != nil)
+			if !tc.wantErr {
+				assert.Equal(t, tc.wanted, tc.given)
+			}
 		})
 	}
 }
@@ -65,6 +153,7 @@ func Test_queueNames(t *testing.T) {
 		overrides map[string]any
 		wantQueue string
 		wantDLQ   string
+		wantErr   bool
 	}{
 		"no override dlq is correct": {
 			arn:       arn.ARN{Resource: "my-queue"},
@@ -80,6 +169,13 @@ func Test_queueNames(t *testing.T) {
 			wantQueue: "other-queue",
 			wantDLQ:   "use-this-one",
 		},
+		"override dlq that is not a string is an error": {
+			arn: arn.ARN{Resource: "other-queue"},
+			overrides: map[string]any{
+				"SQS_DLQ_NAME": float64(1),
+			},
+			wantErr: true,
+		},
 	}
 
 	for name, tc := range cases {
@@ -87,10 +183,11 @@ func Test_queueNames(t *testing.T) {
 			tc := tc
 			t.Parallel()
 
-			queue, dlq := queueNames(tc.arn, tc.overrides)
+			queue, dlq, err := queueNames(tc.arn, tc.overrides)
 
 			assert := assert.New(t)
 
+			assert.Equal(tc.wantErr, err != nil)
 			assert.Equal(tc.wantQueue, queue)
 			assert.Equal(tc.wantDLQ, dlq)
 		})
@@ -0,0 +1,108 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/akijowski/aws-auto-alarm/internal/autoalarm"
+)
+
+// SourceEnvVar is the environment variable that holds the location of external alarm templates.
+const SourceEnvVar = "AWS_AUTO_ALARM_TEMPLATE_SOURCE"
+
+const s3Scheme = "s3://"
+
+// OpenSource returns the fs.FS of alarm templates at location, which is either a local directory or an
+// s3://bucket/prefix URL. The fs.FS has the same layout as the embedded templates: one directory per service.
+// An empty location returns a nil fs.FS, which uses only the embedded templates.
+//
+// Objects in S3 are read once, when the source is opened, so the source should be opened once per process.
+func OpenSource(ctx context.Context, location string, api autoalarm.ObjectsAPI) (fs.FS, error) {
+	if location == "" {
+		return nil, nil
+	}
+
+	if bucketPrefix, ok := strings.CutPrefix(location, s3Scheme); ok {
+		if api == nil {
+			return nil, errors.New("an S3 API is required to load templates from S3")
+		}
+		bucket, prefix, _ := strings.Cut(bucketPrefix, "/")
+		return loadS3(ctx, api, bucket, prefix)
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open template directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template source %s is not a directory", location)
+	}
+
+	return os.DirFS(location), nil
+}
+
+// loadS3 reads every object under the prefix in the bucket into an in-memory fs.FS.
+// Object keys are stored relative to the prefix.
+func loadS3(ctx context.Context, api autoalarm.ObjectsAPI, bucket, prefix string) (fs.FS, error) {
+	if bucket == "" {
+		return nil, errors.New("an S3 bucket is required to load templates from S3")
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	files := make(memFS)
+	paginator := s3.NewListObjectsV2Paginator(api, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list templates in s3://%s/%s: %w", bucket, prefix, err)
+		}
+
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			name := strings.TrimPrefix(key, prefix)
+			if name == "" || strings.HasSuffix(name, "/") || !fs.ValidPath(path.Clean(name)) {
+				continue
+			}
+
+			b, err := getObject(ctx, api, bucket, key)
+			if err != nil {
+				return nil, err
+			}
+			files[path.Clean(name)] = b
+		}
+	}
+
+	return files, nil
+}
+
+func getObject(ctx context.Context, api autoalarm.ObjectsAPI, bucket, key string) ([]byte, error) {
+	out, err := api.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get template s3://%s/%s: %w", bucket, key, err)
+	}
+	defer out.Body.Close()
+
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read template s3://%s/%s: %w", bucket, key, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+const customSQSTemplate = `{
+    "AlarmName": "custom QueueName={{ .Resources.QueueName }}",
+    "MetricName": "ApproximateAgeOfOldestMessage",
+    "Namespace": "AWS/SQS",
+    "Threshold": 300
+}`
+
+// fakeObjectsAPI is an in-memory S3 bucket keyed by object key.
+type fakeObjectsAPI map[string]string
+
+func (f fakeObjectsAPI) ListObjectsV2(_ context.Context, params *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	out := &s3.ListObjectsV2Output{}
+	for key := range f {
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			out.Contents = append(out.Contents, types.Object{Key: aws.String(key)})
+		}
+	}
+
+	return out, nil
+}
+
+func (f fakeObjectsAPI) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(strings.NewReader(f[aws.ToString(params.Key)])),
+	}, nil
+}
+
+func sqsConfig() *config.Config {
+	return &config.Config{
+		ParsedARN: arn.ARN{
+			Partition: "aws",
+			Service:   "sqs",
+			Region:    "us-east-1",
+			AccountID: "123456789012",
+			Resource:  "my-queue",
+		},
+	}
+}
+
+func TestOpenSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sqs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sqs", "custom.json.tmpl"), []byte(customSQSTemplate), 0o644))
+
+	bucket := fakeObjectsAPI{
+		"alarms/sqs/":                 customSQSTemplate,
+		"alarms/sqs/custom.json.tmpl": customSQSTemplate,
+		"other/sqs/ignored.json.tmpl": "{}",
+	}
+
+	cases := map[string]struct {
+		location  string
+		expectErr bool
+		expectNil bool
+	}{
+		"empty location uses embedded templates": {
+			location:  "",
+			expectNil: true,
+		},
+		"local directory": {
+			location: dir,
+		},
+		"missing local directory": {
+			location:  filepath.Join(dir, "missing"),
+			expectErr: true,
+		},
+		"local file": {
+			location:  filepath.Join(dir, "sqs", "custom.json.tmpl"),
+			expectErr: true,
+		},
+		"s3 prefix": {
+			location: "s3://my-bucket/alarms",
+		},
+		"s3 missing bucket": {
+			location:  "s3://",
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := OpenSource(context.TODO(), tc.location, bucket)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.expectNil {
+				assert.Nil(t, src)
+				return
+			}
+
+			alarms, err := NewFileLoader(context.TODO(), sqsConfig(), stubMapper{"QueueName": "my-queue"}, src).Load(context.TODO())
+			require.NoError(t, err)
+			require.Len(t, alarms, 1)
+			assert.Equal(t, "custom QueueName=my-queue", aws.ToString(alarms[0].AlarmName))
+		})
+	}
+}
+
+func TestMemFS(t *testing.T) {
+	t.Parallel()
+
+	files := memFS{
+		"sqs/custom.json.tmpl":        []byte(customSQSTemplate),
+		"sqs/other.json.tmpl":         []byte("{}"),
+		"lambda/errors/nested.tmpl":   []byte("{}"),
+		"dynamodb/system-errors.tmpl": []byte("{}"),
+	}
+
+	require.NoError(t, fstest.TestFS(files,
+		"sqs/custom.json.tmpl", "sqs/other.json.tmpl", "lambda/errors/nested.tmpl", "dynamodb/system-errors.tmpl"))
+}
+
+func TestFileLoader_Load_fallback(t *testing.T) {
+	t.Parallel()
+
+	src, err := OpenSource(context.TODO(), "s3://my-bucket/alarms", fakeObjectsAPI{
+		"alarms/dynamodb/custom.json.tmpl": "{}",
+	})
+	require.NoError(t, err)
+
+	mapper := stubMapper{"QueueName": "my-queue", "DLQName": ""}
+	alarms, err := NewFileLoader(context.TODO(), sqsConfig(), mapper, src).Load(context.TODO())
+	require.NoError(t, err)
+
+	names := make([]string, 0)
+	for _, alarm := range alarms {
+		names = append(names, aws.ToString(alarm.AlarmName))
+	}
+	assert.Equal(t, []string{"AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=my-queue"}, names)
+}
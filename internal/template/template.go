@@ -6,12 +6,16 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
 	"text/template"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/rs/zerolog/log"
 
 	"github.com/akijowski/aws-auto-alarm/internal/config"
 )
@@ -21,8 +25,22 @@ var (
 	content embed.FS
 )
 
+// templateSources returns the fs.FS sources to search for templates, in order, ending with the embedded templates.
+func templateSources(src fs.FS) []fs.FS {
+	embedded, err := fs.Sub(content, "templates")
+	if err != nil {
+		panic(err)
+	}
+
+	if src == nil {
+		return []fs.FS{embedded}
+	}
+
+	return []fs.FS{src, embedded}
+}
+
 type ResourceMapper interface {
-	Map(ctx context.Context) map[string]any
+	Map(ctx context.Context) (map[string]any, error)
 }
 
 // alarmData is what is applied to each alarm template.
@@ -33,36 +51,122 @@ type alarmData struct {
 	ARN arn.ARN
 	// Resources is a map of data that can hold specific information based on the ARN resource type.
 	Resources map[string]any
-	// Tags is a map of tags to apply to the alarm.
+	// Tags is a map of tags to apply to the alarm. It has the propagated resource tags and the configured tags,
+	// which replace resource tags with the same key.
 	Tags map[string]string
+	// overrides are the override fields of the template being rendered. See Threshold.
+	overrides map[string]any
 }
 
-func newAlarmData(ctx context.Context, cfg *config.Config, m ResourceMapper) *alarmData {
+// Threshold returns the Threshold override of the template being rendered, or fallback when it is not overridden.
+// Templates call it for the threshold in the alarm name and description, such as {{ .Threshold "100" }}, so that an
+// overridden threshold is named correctly.
+func (d *alarmData) Threshold(fallback string) string {
+	if threshold, ok := d.overrides["Threshold"]; ok {
+		return fmt.Sprint(threshold)
+	}
+
+	return fallback
+}
+
+func newAlarmData(ctx context.Context, cfg *config.Config, m ResourceMapper) (*alarmData, error) {
+	resources, err := m.Map(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := limitPropagatedTags(ctx, config.PropagatedTags(cfg), cfg.Tags)
+	maps.Copy(tags, cfg.Tags)
+
 	return &alarmData{
 		AlarmPrefix: cfg.AlarmPrefix,
 		ARN:         cfg.ParsedARN,
-		Resources:   m.Map(ctx),
-		Tags:        cfg.Tags,
-	}
+		Resources:   resources,
+		Tags:        tags,
+	}, nil
 }
 
-func newAlarm(t *template.Template, data *alarmData, base *cloudwatch.PutMetricAlarmInput) (*cloudwatch.PutMetricAlarmInput, error) {
-	buf := new(bytes.Buffer)
+// limitPropagatedTags drops propagated tags, in reverse order of their keys, until they fit within MaxAlarmTags
+// together with the configured tags and the reservedAlarmTags, and logs a warning with the dropped keys. Propagated
+// tags with the key of a configured tag are replaced by it, so they are not counted.
+func limitPropagatedTags(ctx context.Context, propagated, configured map[string]string) map[string]string {
+	keys := make([]string, 0, len(propagated))
+	for key := range propagated {
+		if _, ok := configured[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
 
-	input := new(cloudwatch.PutMetricAlarmInput)
-	copyAlarmBase(base, input)
+	limit := max(MaxAlarmTags-reservedAlarmTags-len(configured), 0)
+	if len(keys) <= limit {
+		return propagated
+	}
 
-	applyTags(input, data)
+	slices.Sort(keys)
+	dropped := keys[limit:]
+	for _, key := range dropped {
+		delete(propagated, key)
+	}
+	log.Ctx(ctx).Warn().Strs("dropped_tags", dropped).Int("limit", MaxAlarmTags).
+		Msg("too many propagated tags for the alarm, dropping the last of them")
+
+	return propagated
+}
+
+// alarmMeta holds the fields of an alarm template that are not part of the cloudwatch.PutMetricAlarmInput.
+type alarmMeta struct {
+	// Severity is the severity tier of the alarm, such as critical. See config.Severities.
+	Severity string `json:"Severity"`
+}
+
+// newAlarms executes the template and returns the alarms it describes.
+// A template renders either a single alarm object or an array of alarm objects, such as one alarm per index of a
+// resource. An empty array renders no alarms. Alarms that declare a Severity use the actions of that severity, when
+// they are configured. Names and descriptions that are too long for CloudWatch are shortened, see limitAlarm.
+func newAlarms(t *template.Template, data *alarmData, base *cloudwatch.PutMetricAlarmInput, severities map[string]config.Route) ([]*cloudwatch.PutMetricAlarmInput, error) {
+	buf := new(bytes.Buffer)
 
 	if err := t.Execute(buf, data); err != nil {
 		return nil, fmt.Errorf("unable to template alarm: %w", err)
 	}
 
-	if err := json.Unmarshal(buf.Bytes(), input); err != nil {
-		return nil, fmt.Errorf("unable to parse json: %w", err)
+	rendered := bytes.TrimSpace(buf.Bytes())
+	docs := []json.RawMessage{rendered}
+	if bytes.HasPrefix(rendered, []byte("[")) {
+		if err := json.Unmarshal(rendered, &docs); err != nil {
+			return nil, fmt.Errorf("unable to parse json: %w", err)
+		}
+	}
+
+	alarms := make([]*cloudwatch.PutMetricAlarmInput, 0, len(docs))
+	for _, doc := range docs {
+		input := new(cloudwatch.PutMetricAlarmInput)
+		copyAlarmBase(base, input)
+
+		applyTags(input, data)
+
+		if err := json.Unmarshal(doc, input); err != nil {
+			return nil, fmt.Errorf("unable to parse json: %w", err)
+		}
+
+		meta := new(alarmMeta)
+		if err := json.Unmarshal(doc, meta); err != nil {
+			return nil, fmt.Errorf("unable to parse json: %w", err)
+		}
+		if meta.Severity != "" {
+			if err := applySeverity(input, meta.Severity, severities); err != nil {
+				return nil, fmt.Errorf("alarm %s: %w", aws.ToString(input.AlarmName), err)
+			}
+		}
+
+		if err := limitAlarm(input); err != nil {
+			return nil, err
+		}
+
+		alarms = append(alarms, input)
 	}
 
-	return input, nil
+	return alarms, nil
 }
 
 func applyTags(input *cloudwatch.PutMetricAlarmInput, data *alarmData) {
@@ -78,6 +182,34 @@ func applyTags(input *cloudwatch.PutMetricAlarmInput, data *alarmData) {
 	input.Tags = append(input.Tags, extraTags...)
 }
 
+// applySeverity tags the alarm with its severity and replaces each action list that the severity sets.
+func applySeverity(input *cloudwatch.PutMetricAlarmInput, severity string, severities map[string]config.Route) error {
+	if err := config.ValidSeverity(severity); err != nil {
+		return err
+	}
+
+	input.Tags = append(input.Tags, types.Tag{
+		Key:   aws.String("AWS_AUTO_ALARM_SEVERITY"),
+		Value: aws.String(severity),
+	})
+
+	route, ok := severities[severity]
+	if !ok {
+		return nil
+	}
+	if len(route.AlarmActions) > 0 {
+		input.AlarmActions = route.AlarmActions
+	}
+	if len(route.OKActions) > 0 {
+		input.OKActions = route.OKActions
+	}
+	if len(route.InsufficientDataActions) > 0 {
+		input.InsufficientDataActions = route.InsufficientDataActions
+	}
+
+	return nil
+}
+
 func awsTags(m map[string]string) []types.Tag {
 	tags := make([]types.Tag, 0)
 	for k, v := range m {
@@ -94,5 +226,6 @@ func copyAlarmBase(src, dest *cloudwatch.PutMetricAlarmInput) {
 	dest.ActionsEnabled = src.ActionsEnabled
 	dest.AlarmActions = src.AlarmActions
 	dest.OKActions = src.OKActions
+	dest.InsufficientDataActions = src.InsufficientDataActions
 	dest.Tags = src.Tags
 }
@@ -12,18 +12,31 @@ variable "aws_region" {
   }
 }
 
+variable "template_source" {
+  description = "s3://bucket/prefix location of external alarm templates. Empty uses only the embedded templates"
+  type        = string
+  default     = ""
+}
+
+locals {
+  lambda_timeout = 900
+}
+
 module "sqs" {
   source = "../modules/sqs"
 
   queue_name      = var.project_name
   event_rule_name = "${var.project_name}-sqs"
+
+  visibility_timeout_seconds = local.lambda_timeout
 }
 
 module "iam_role" {
   source = "../modules/iam_role"
 
-  role_name     = var.project_name
-  sqs_queue_arn = module.sqs.arn
+  role_name       = var.project_name
+  sqs_queue_arn   = module.sqs.arn
+  template_source = var.template_source
 
   providers = {
     aws = aws.global
@@ -37,7 +50,8 @@ module "lambda" {
   abs_path_to_archive_file = abspath("${path.module}/../../out/bootstrap.zip")
   lambda_role_arn          = module.iam_role.lambda_role_arn
   sqs_queue_arn            = module.sqs.arn
-
+  timeout                  = local.lambda_timeout
+  template_source          = var.template_source
 }
 
 module "logging" {
@@ -51,7 +65,7 @@ module "eventbridge" {
 
   rule_name        = "${var.project_name}-sqs"
   target_sqs_arn   = module.sqs.arn
-  allowed_services = toset(["sqs"])
+  allowed_services = toset(["dynamodb", "events", "lambda", "sqs"])
 }
 
 output "sqs_queue_arn" {
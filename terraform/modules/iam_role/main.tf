@@ -16,8 +16,21 @@ variable "role_name" {
   type        = string
 }
 
+variable "template_source" {
+  description = "s3://bucket/prefix location of external alarm templates that the lambda may read. Other locations grant no S3 access"
+  type        = string
+  default     = ""
+}
+
 data "aws_caller_identity" "current" {}
 
+locals {
+  # bucket/prefix of the template source, or empty when the templates are not in S3
+  template_s3_path   = startswith(var.template_source, "s3://") ? trimsuffix(trimprefix(var.template_source, "s3://"), "/") : ""
+  template_s3_bucket = split("/", local.template_s3_path)[0]
+  template_s3_keys   = local.template_s3_path == local.template_s3_bucket ? "*" : "${trimprefix(local.template_s3_path, "${local.template_s3_bucket}/")}/*"
+}
+
 data "aws_iam_policy_document" "lambda_access" {
   statement {
     effect = "Allow"
@@ -64,6 +77,71 @@ data "aws_iam_policy_document" "lambda" {
 
     resources = ["arn:aws:cloudwatch:*:${data.aws_caller_identity.current.account_id}:alarm:*"]
   }
+
+  statement {
+    sid = "DescribeResources"
+
+    effect    = "Allow"
+    actions   = ["dynamodb:DescribeTable"]
+    resources = ["arn:aws:dynamodb:*:${data.aws_caller_identity.current.account_id}:table/*"]
+  }
+
+  statement {
+    sid = "DescribeQueues"
+
+    effect = "Allow"
+    actions = [
+      "sqs:GetQueueUrl",
+      "sqs:GetQueueAttributes"
+    ]
+    resources = ["arn:aws:sqs:*:${data.aws_caller_identity.current.account_id}:*"]
+  }
+
+  statement {
+    sid = "DescribeFunctions"
+
+    effect    = "Allow"
+    actions   = ["lambda:GetFunction"]
+    resources = ["arn:aws:lambda:*:${data.aws_caller_identity.current.account_id}:function:*"]
+  }
+
+  statement {
+    sid = "FindResources"
+
+    effect    = "Allow"
+    actions   = ["tag:GetResources"]
+    resources = ["*"]
+  }
+
+  dynamic "statement" {
+    for_each = local.template_s3_bucket == "" ? [] : [local.template_s3_bucket]
+
+    content {
+      sid = "ListTemplates"
+
+      effect    = "Allow"
+      actions   = ["s3:ListBucket"]
+      resources = ["arn:aws:s3:::${statement.value}"]
+
+      condition {
+        test     = "StringLike"
+        variable = "s3:prefix"
+        values   = [local.template_s3_keys]
+      }
+    }
+  }
+
+  dynamic "statement" {
+    for_each = local.template_s3_bucket == "" ? [] : [local.template_s3_bucket]
+
+    content {
+      sid = "ReadTemplates"
+
+      effect    = "Allow"
+      actions   = ["s3:GetObject"]
+      resources = ["arn:aws:s3:::${statement.value}/${local.template_s3_keys}"]
+    }
+  }
 }
 
 resource "aws_iam_role" "lambda" {
@@ -22,6 +22,64 @@ variable "sqs_queue_arn" {
   type        = string
 }
 
+variable "timeout" {
+  description = "Lambda timeout in seconds, sized for a full-account sweep. The SQS queue visibility timeout must be at least as long"
+  type        = number
+  default     = 900
+}
+
+variable "sweep_schedule_expression" {
+  description = "EventBridge schedule expression for the full-account alarm sweep"
+  type        = string
+  default     = "rate(1 day)"
+}
+
+variable "unmute_schedule_expression" {
+  description = "EventBridge schedule expression for ending alarm mutes, which bounds how long a mute can last past its time"
+  type        = string
+  default     = "rate(15 minutes)"
+}
+
+variable "action_routes" {
+  description = "Routing aliases that resources can use in place of action ARNs, by alias name"
+  type = map(object({
+    alarmActions            = optional(list(string), [])
+    okActions               = optional(list(string), [])
+    insufficientDataActions = optional(list(string), [])
+  }))
+  default = {}
+}
+
+variable "propagate_tags" {
+  description = "Resource tag keys, or key prefixes ending with *, to copy onto the alarms of every resource"
+  type        = list(string)
+  default     = []
+}
+
+variable "template_source" {
+  description = "Local directory or s3://bucket/prefix location of external alarm templates. Empty uses only the embedded templates"
+  type        = string
+  default     = ""
+}
+
+variable "put_concurrency" {
+  description = "Number of PutMetricAlarm calls made at once by each invocation"
+  type        = number
+  default     = 3
+}
+
+variable "put_requests_per_second" {
+  description = "Rate limit of the PutMetricAlarm calls made by each invocation, below the CloudWatch quota of 3 per second"
+  type        = number
+  default     = 2
+}
+
+variable "tag_requests_per_second" {
+  description = "Rate limit of the CloudWatch alarm tag calls made by each invocation"
+  type        = number
+  default     = 5
+}
+
 resource "aws_lambda_function" "this" {
   function_name = var.lambda_name
   description   = "Tweek Week 2024 project"
@@ -31,7 +89,7 @@ resource "aws_lambda_function" "this" {
   runtime       = "provided.al2023"
   architectures = ["arm64"]
 
-  timeout = 30
+  timeout = var.timeout
   publish = true
 
   filename         = var.abs_path_to_archive_file
@@ -39,7 +97,14 @@ resource "aws_lambda_function" "this" {
 
   environment {
     variables = {
-      "AWS_AUTO_ALARM_LOG_LEVEL" = "info"
+      "AWS_AUTO_ALARM_LOG_LEVEL"       = "info"
+      "AWS_AUTO_ALARM_CONCURRENCY"     = "4"
+      "AWS_AUTO_ALARM_ROUTES"          = jsonencode(var.action_routes)
+      "AWS_AUTO_ALARM_PROPAGATETAGS"   = join(",", var.propagate_tags)
+      "AWS_AUTO_ALARM_PUT_CONCURRENCY" = tostring(var.put_concurrency)
+      "AWS_AUTO_ALARM_PUT_RPS"         = tostring(var.put_requests_per_second)
+      "AWS_AUTO_ALARM_TAG_RPS"         = tostring(var.tag_requests_per_second)
+      "AWS_AUTO_ALARM_TEMPLATE_SOURCE" = var.template_source
     }
   }
 }
@@ -54,6 +119,61 @@ resource "aws_lambda_event_source_mapping" "sqs" {
   function_name    = aws_lambda_alias.this.arn
   event_source_arn = var.sqs_queue_arn
   enabled          = true
+
+  function_response_types = ["ReportBatchItemFailures"]
+}
+
+resource "aws_cloudwatch_event_rule" "sweep" {
+  name                = "${var.lambda_name}-sweep"
+  description         = "Reconcile alarms for every enabled resource on a schedule"
+  schedule_expression = var.sweep_schedule_expression
+}
+
+resource "aws_cloudwatch_event_target" "sweep" {
+  arn       = aws_lambda_alias.this.arn
+  rule      = aws_cloudwatch_event_rule.sweep.name
+  target_id = "SweepLambda"
+}
+
+resource "aws_lambda_permission" "sweep" {
+  statement_id  = "AllowSweepSchedule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.this.function_name
+  qualifier     = aws_lambda_alias.this.name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.sweep.arn
+}
+
+resource "aws_cloudwatch_event_rule" "unmute" {
+  name                = "${var.lambda_name}-unmute"
+  description         = "Put the alarms of resources whose alarm mute has ended on a schedule"
+  schedule_expression = var.unmute_schedule_expression
+}
+
+resource "aws_cloudwatch_event_target" "unmute" {
+  arn       = aws_lambda_alias.this.arn
+  rule      = aws_cloudwatch_event_rule.unmute.name
+  target_id = "UnmuteLambda"
+
+  # the unmute action in the detail routes the scheduled event to the unmute instead of the sweep
+  input_transformer {
+    input_paths = {
+      id   = "$.id"
+      time = "$.time"
+    }
+    input_template = <<-EOT
+      {"id": <id>, "time": <time>, "source": "aws.events", "detail-type": "Scheduled Event", "detail": {"action": "unmute"}}
+    EOT
+  }
+}
+
+resource "aws_lambda_permission" "unmute" {
+  statement_id  = "AllowUnmuteSchedule"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.this.function_name
+  qualifier     = aws_lambda_alias.this.name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.unmute.arn
 }
 
 output "arn" {
@@ -8,6 +8,12 @@ variable "event_rule_name" {
   type        = string
 }
 
+variable "visibility_timeout_seconds" {
+  description = "Visibility timeout of the SQS queue, which must be at least the timeout of the lambda function that consumes it"
+  type        = number
+  default     = 900
+}
+
 data "aws_caller_identity" "current" {}
 
 data "aws_iam_policy_document" "this" {
@@ -34,7 +40,8 @@ data "aws_iam_policy_document" "this" {
 }
 
 resource "aws_sqs_queue" "this" {
-  name = var.queue_name
+  name                       = var.queue_name
+  visibility_timeout_seconds = var.visibility_timeout_seconds
   redrive_policy = jsonencode({
     deadLetterTargetArn = aws_sqs_queue.dlq.arn
     maxReceiveCount     = 3
@@ -30,6 +30,22 @@ func TestOutput(t *testing.T) {
 		name     string
 		fileName string
 	}{
+		{
+			name:     "dynamodb",
+			fileName: "fixtures/cli/dynamodb.json",
+		},
+		{
+			name:     "events",
+			fileName: "fixtures/cli/events.json",
+		},
+		{
+			name:     "events_default_bus",
+			fileName: "fixtures/cli/events_default_bus.json",
+		},
+		{
+			name:     "lambda_alias",
+			fileName: "fixtures/cli/lambda_alias.json",
+		},
 		{
 			name:     "sqs",
 			fileName: "fixtures/cli/sqs.json",
@@ -73,7 +89,7 @@ func TestOutput(t *testing.T) {
 				Logger().
 				WithContext(context.Background())
 
-			c := cli.New(config, nil, buf)
+			c := cli.New(config, nil, nil, nil, nil, buf)
 			err = c.Run(ctx)
 			require.NoError(err)
 
@@ -0,0 +1,275 @@
+package acceptance
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akijowski/aws-auto-alarm/internal/cli"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+type fakeMetricAlarmAPI struct {
+	mu      sync.Mutex
+	putErr  error
+	puts    []string
+	deletes []string
+}
+
+func (f *fakeMetricAlarmAPI) PutMetricAlarm(_ context.Context, in *cloudwatch.PutMetricAlarmInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricAlarmOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+	f.puts = append(f.puts, aws.ToString(in.AlarmName))
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) DeleteAlarms(_ context.Context, in *cloudwatch.DeleteAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DeleteAlarmsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deletes = append(f.deletes, in.AlarmNames...)
+	return &cloudwatch.DeleteAlarmsOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) DescribeAlarms(_ context.Context, _ *cloudwatch.DescribeAlarmsInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.DescribeAlarmsOutput, error) {
+	return &cloudwatch.DescribeAlarmsOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) ListTagsForResource(_ context.Context, _ *cloudwatch.ListTagsForResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.ListTagsForResourceOutput, error) {
+	return &cloudwatch.ListTagsForResourceOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) TagResource(_ context.Context, _ *cloudwatch.TagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.TagResourceOutput, error) {
+	return &cloudwatch.TagResourceOutput{}, nil
+}
+
+func (f *fakeMetricAlarmAPI) UntagResource(_ context.Context, _ *cloudwatch.UntagResourceInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.UntagResourceOutput, error) {
+	return &cloudwatch.UntagResourceOutput{}, nil
+}
+
+func TestExecute(t *testing.T) {
+	t.Parallel()
+
+	queueARN := "arn:aws:sqs:us-east-1:123456789012:test-queue"
+	managed := []types.ResourceTagMapping{
+		{ResourceARN: aws.String("arn:aws:cloudwatch:us-east-1:123456789012:alarm:second managed alarm")},
+		{ResourceARN: aws.String("arn:aws:cloudwatch:us-east-1:123456789012:alarm:first managed alarm")},
+	}
+
+	cases := map[string]struct {
+		args        []string
+		templates   fs.FS
+		putErr      error
+		wantCode    int
+		wantStdout  []string
+		wantStderr  string
+		wantPuts    int
+		wantDeletes []string
+	}{
+		"render with flags that override the file": {
+			args:     []string{"render", "--file", "fixtures/commands/sqs.json", "--arn", queueARN, "--alarm-prefix", "flagged"},
+			wantCode: cli.ExitOK,
+			wantStdout: []string{
+				`"AlarmName": "flagged AWS/SQS ApproximateNumberOfMessagesVisible \u003e 100 QueueName=test-queue"`,
+				`"arn:aws:sns:us-east-1:0123456789012:topic/Foo"`,
+			},
+		},
+		"render a YAML file": {
+			args:       []string{"render", "--file", "fixtures/commands/sqs.yaml"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{`"AlarmName": "yaml AWS/SQS ApproximateNumberOfMessagesVisible \u003e 100 QueueName=test-queue"`},
+		},
+		"render resolves action aliases": {
+			args:     []string{"render", "--file", "fixtures/commands/routes.yaml"},
+			wantCode: cli.ExitOK,
+			wantStdout: []string{
+				"\"AlarmActions\": [\n      \"arn:aws:sns:us-east-1:0123456789012:pager\"\n    ]",
+				"\"InsufficientDataActions\": [\n      \"arn:aws:sns:us-east-1:0123456789012:tickets\"\n    ]",
+			},
+		},
+		"render rejects unknown action aliases": {
+			args:     []string{"render", "--file", "fixtures/commands/routes.yaml", "--alarm-action", "team-unknown"},
+			wantCode: cli.ExitInvalid,
+		},
+		"render applies template overrides": {
+			args:       []string{"render", "--file", "fixtures/commands/overrides.json"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{`"EvaluationPeriods": 5,`, `"Threshold": 1000,`},
+		},
+		"render rejects unknown template override fields": {
+			args:     []string{"render", "--file", "fixtures/commands/overrides_invalid.json"},
+			wantCode: cli.ExitInvalid,
+		},
+		"render as CloudFormation": {
+			args:     []string{"render", "--file", "fixtures/commands/sqs.json", "--output", "cloudformation"},
+			wantCode: cli.ExitOK,
+			wantStdout: []string{
+				`"AWSTemplateFormatVersion": "2010-09-09"`,
+				`"Type": "AWS::CloudWatch::Alarm"`,
+				`"AlarmName": "AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=test-queue"`,
+			},
+		},
+		"render as Terraform": {
+			args:     []string{"render", "--file", "fixtures/commands/sqs.json", "--output", "terraform"},
+			wantCode: cli.ExitOK,
+			wantStdout: []string{
+				`resource "aws_cloudwatch_metric_alarm" "aws_sqs_approximatenumberofmessagesvisible_100_queuename_test_queue_`,
+				`alarm_name          = "AWS/SQS ApproximateNumberOfMessagesVisible > 100 QueueName=test-queue"`,
+			},
+		},
+		"render rejects unknown outputs": {
+			args:     []string{"render", "--file", "fixtures/commands/sqs.json", "--output", "xml"},
+			wantCode: cli.ExitInvalid,
+		},
+		"render cannot delete as Terraform": {
+			args:     []string{"render", "--arn", queueARN, "--delete", "--output", "terraform"},
+			wantCode: cli.ExitFailure,
+		},
+		"apply puts the alarms": {
+			args:        []string{"apply", "--arn", queueARN, "--alarm-action", "arn:aws:sns:us-east-1:123456789012:alerts"},
+			wantCode:    cli.ExitOK,
+			wantPuts:    2,
+			wantDeletes: []string{"second managed alarm", "first managed alarm"},
+		},
+		"apply ignores dry run in the file": {
+			args:        []string{"apply", "--file", "fixtures/commands/sqs.json"},
+			wantCode:    cli.ExitOK,
+			wantPuts:    2,
+			wantDeletes: []string{"second managed alarm", "first managed alarm"},
+		},
+		"apply failures": {
+			args:     []string{"apply", "--arn", queueARN},
+			putErr:   &smithy.GenericAPIError{Code: "ValidationError", Fault: smithy.FaultClient},
+			wantCode: cli.ExitFailure,
+		},
+		"delete removes the managed alarms": {
+			args:        []string{"delete", "--arn", queueARN},
+			wantCode:    cli.ExitOK,
+			wantDeletes: []string{"second managed alarm", "first managed alarm"},
+		},
+		"render a delete": {
+			args:       []string{"render", "--arn", queueARN, "--delete"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{`"second managed alarm"`},
+		},
+		"plan": {
+			args:       []string{"plan", "--arn", queueARN},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{"Plan: 2 to create, 0 to update, 0 to delete, 0 unchanged."},
+		},
+		"list the managed alarms": {
+			args:       []string{"list", "--arn", queueARN},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{"first managed alarm\nsecond managed alarm\n"},
+		},
+		"validate a valid config": {
+			args:       []string{"validate", "--arn", queueARN},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{"2 alarms are valid for " + queueARN},
+		},
+		"validate metric math with more than one returned query": {
+			args: []string{"validate", "--arn", queueARN},
+			templates: fstest.MapFS{
+				"sqs/ratio.json.tmpl": {Data: []byte(`{
+					"AlarmName": "ratio",
+					"ComparisonOperator": "LessThanThreshold",
+					"EvaluationPeriods": 1,
+					"Metrics": [
+						{"Id": "ratio", "Expression": "deleted / received"},
+						{"Id": "deleted", "MetricStat": {"Metric": {"MetricName": "NumberOfMessagesDeleted"}, "Period": 60, "Stat": "Sum"}},
+						{"Id": "received", "MetricStat": {"Metric": {"MetricName": "NumberOfMessagesReceived"}, "Period": 60, "Stat": "Sum"}, "ReturnData": false}
+					]
+				}`)},
+			},
+			wantCode: cli.ExitInvalid,
+		},
+		"apply rejects metric math with more than one returned query": {
+			args: []string{"apply", "--arn", queueARN},
+			templates: fstest.MapFS{
+				"sqs/ratio.json.tmpl": {Data: []byte(`{
+					"AlarmName": "ratio",
+					"ComparisonOperator": "LessThanThreshold",
+					"EvaluationPeriods": 1,
+					"Metrics": [
+						{"Id": "ratio", "Expression": "deleted / received"},
+						{"Id": "deleted", "MetricStat": {"Metric": {"MetricName": "NumberOfMessagesDeleted"}, "Period": 60, "Stat": "Sum"}},
+						{"Id": "received", "MetricStat": {"Metric": {"MetricName": "NumberOfMessagesReceived"}, "Period": 60, "Stat": "Sum"}, "ReturnData": false}
+					]
+				}`)},
+			},
+			wantCode: cli.ExitInvalid,
+		},
+		"validate an invalid action": {
+			args:     []string{"validate", "--arn", queueARN, "--alarm-action", "not-an-arn"},
+			wantCode: cli.ExitInvalid,
+		},
+		"missing ARN": {
+			args:     []string{"render"},
+			wantCode: cli.ExitInvalid,
+		},
+		"missing file": {
+			args:     []string{"render", "--file", "fixtures/commands/missing.json"},
+			wantCode: cli.ExitInvalid,
+		},
+		"unknown command": {
+			args:       []string{"destroy"},
+			wantCode:   cli.ExitUsage,
+			wantStderr: `unknown command "destroy"`,
+		},
+		"unknown flag": {
+			args:     []string{"list", "--tag", "AWS_AUTO_ALARM_ENABLED=true"},
+			wantCode: cli.ExitUsage,
+		},
+		"unexpected arguments": {
+			args:       []string{"apply", "--arn", queueARN, "extra"},
+			wantCode:   cli.ExitUsage,
+			wantStderr: "unexpected arguments: extra",
+		},
+		"help": {
+			args:       []string{"apply", "--help"},
+			wantCode:   cli.ExitOK,
+			wantStderr: "Usage: aws_auto_alarm apply [flags]",
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := zerolog.New(zerolog.NewTestWriter(t)).WithContext(context.Background())
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+			metricAPI := &fakeMetricAlarmAPI{putErr: tc.putErr}
+			newClients := func(_ context.Context, _ *config.Config) (*cli.Clients, error) {
+				return &cli.Clients{
+					MetricAPI:   metricAPI,
+					ResourceAPI: &fakeResourcesAPI{mappings: managed},
+					Templates:   tc.templates,
+				}, nil
+			}
+
+			code := cli.Execute(ctx, tc.args, stdout, stderr, newClients)
+
+			assert.Equal(t, tc.wantCode, code, stderr.String())
+			for _, want := range tc.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+			assert.Contains(t, stderr.String(), tc.wantStderr)
+			assert.Len(t, metricAPI.puts, tc.wantPuts)
+			assert.ElementsMatch(t, tc.wantDeletes, metricAPI.deletes)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package acceptance
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akijowski/aws-auto-alarm/internal/cli"
+	"github.com/akijowski/aws-auto-alarm/internal/config"
+)
+
+type fakeResourcesAPI struct {
+	mappings []types.ResourceTagMapping
+	inputs   []*resourcegroupstaggingapi.GetResourcesInput
+}
+
+func (f *fakeResourcesAPI) GetResources(_ context.Context, in *resourcegroupstaggingapi.GetResourcesInput, _ ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	f.inputs = append(f.inputs, in)
+	return &resourcegroupstaggingapi.GetResourcesOutput{ResourceTagMappingList: f.mappings}, nil
+}
+
+func resource(resourceARN string, tags map[string]string) types.ResourceTagMapping {
+	mapping := types.ResourceTagMapping{ResourceARN: aws.String(resourceARN)}
+	for k, v := range tags {
+		mapping.Tags = append(mapping.Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return mapping
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeResourcesAPI{
+		mappings: []types.ResourceTagMapping{
+			resource("arn:aws:sqs:us-east-1:123456789012:queue-a", map[string]string{
+				"AWS_AUTO_ALARM_ENABLED":     "true",
+				"AWS_AUTO_ALARM_ALARMPREFIX": "team-a",
+			}),
+			resource("arn:aws:sqs:us-east-1:123456789012:queue-b", map[string]string{
+				"AWS_AUTO_ALARM_ENABLED":   "true",
+				"AWS_AUTO_ALARM_OVERRIDES": "not-json",
+			}),
+			resource("arn:aws:s3:::my-bucket", map[string]string{
+				"AWS_AUTO_ALARM_ENABLED": "true",
+			}),
+		},
+	}
+
+	cfg := &config.Config{
+		DryRun: true,
+		Discover: &config.Discover{
+			Tags:     map[string]string{"AWS_AUTO_ALARM_ENABLED": "true"},
+			Services: []string{"sqs"},
+		},
+	}
+
+	ctx := zerolog.New(zerolog.NewTestWriter(t)).WithContext(context.Background())
+	buf := new(bytes.Buffer)
+
+	err := cli.New(cfg, nil, api, nil, nil, buf).Run(ctx)
+	assert.EqualError(t, err, "1 of 3 resources failed")
+
+	require.Len(t, api.inputs, 1)
+	assert.Equal(t, []string{"sqs"}, api.inputs[0].ResourceTypeFilters)
+
+	out := buf.String()
+	assert.Contains(t, out, `"AlarmName": "team-a AWS/SQS ApproximateNumberOfMessagesVisible`)
+	assert.Regexp(t, `RESOURCE\s+ACTION\s+MODE\s+RESULT`, out)
+	assert.Regexp(t, `arn:aws:sqs:us-east-1:123456789012:queue-a\s+upsert\s+json\s+ok`, out)
+	assert.Regexp(t, `arn:aws:sqs:us-east-1:123456789012:queue-b\s+upsert\s+json\s+error: unable to parse AWS_AUTO_ALARM_OVERRIDES`, out)
+	assert.Regexp(t, `arn:aws:s3:::my-bucket\s+skip\s+json\s+skipped: service s3 is not supported`, out)
+}